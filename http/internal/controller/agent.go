@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"http/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AgentController struct {
+	agentService *service.AgentService
+}
+
+type createAgentRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func NewAgentController(db *gorm.DB, api *gin.RouterGroup) *AgentController {
+	agentService, err := service.NewAgentService(db)
+	if err != nil {
+		log.Fatalf("Failed to create agent service: %v", err)
+	}
+
+	a := &AgentController{
+		agentService: agentService,
+	}
+
+	api.GET("/agents", a.getAgents)
+	api.POST("/agents", a.createAgent)
+	api.DELETE("/agents/:agent_id", a.deleteAgent)
+
+	return a
+}
+
+func (a *AgentController) getAgents(c *gin.Context) {
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	agents, err := a.agentService.GetAgentsByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch agents",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    agents,
+		"count":   len(agents),
+		"message": "Agents fetched successfully",
+	})
+}
+
+func (a *AgentController) createAgent(c *gin.Context) {
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	var req createAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	agent, token, err := a.agentService.CreateAgent(userID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create agent",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    agent,
+		"token":   token,
+		"message": "Agent created successfully, store the token now as it is not shown again",
+	})
+}
+
+func (a *AgentController) deleteAgent(c *gin.Context) {
+	userID, ok := userIDFromClaims(c)
+	if !ok {
+		return
+	}
+
+	agentID, err := strconv.ParseUint(c.Param("agent_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid agent ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := a.agentService.DeleteAgent(userID, uint(agentID)); err != nil {
+		if err.Error() == "agent not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Agent not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete agent",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agent deleted successfully",
+	})
+}
+
+// userIDFromClaims reads the user ID set by the JWT middleware and responds
+// with 401 when it is missing.
+func userIDFromClaims(c *gin.Context) (uint, bool) {
+	if claimsVal, exists := c.Get("user"); exists {
+		if claims, ok := claimsVal.(*service.Claims); ok {
+			if uid, err := strconv.ParseUint(claims.UserID, 10, 32); err == nil {
+				return uint(uid), true
+			}
+		}
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+	return 0, false
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -26,6 +27,7 @@ func NewAnalyticsController(db *gorm.DB, api *gin.RouterGroup) *AnalyticsControl
 
 	api.GET("/analytics/uptime", a.getUptimeStats)
 	api.GET("/products/:product_id/uptime-stats", a.getProductUptimeStats)
+	api.GET("/products/:product_id/latency", a.getProductLatencyStats)
 
 	return a
 }
@@ -56,6 +58,13 @@ func (a *AnalyticsController) getUptimeStats(c *gin.Context) {
 
 	stats, err := a.analyticsService.GetUptimeStats(uint(productID), period, startDate, endDate)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid period") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid period",
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch uptime stats",
 			"message": err.Error(),
@@ -87,6 +96,13 @@ func (a *AnalyticsController) getProductUptimeStats(c *gin.Context) {
 
 	stats, err := a.analyticsService.GetUptimeStats(uint(productID), period, startDate, endDate)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid period") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid period",
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch uptime stats",
 			"message": err.Error(),
@@ -99,3 +115,49 @@ func (a *AnalyticsController) getProductUptimeStats(c *gin.Context) {
 		"message": "Uptime stats fetched successfully",
 	})
 }
+
+func (a *AnalyticsController) getProductLatencyStats(c *gin.Context) {
+	productIDStr := c.Param("product_id")
+	productID, err := strconv.ParseUint(productIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Parse query parameters
+	period := c.DefaultQuery("period", "24h")
+	interval := c.Query("interval")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	stats, err := a.analyticsService.GetLatencyStats(uint(productID), period, interval, startDate, endDate)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid interval") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid interval",
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid period") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid period",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch latency stats",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    stats,
+		"message": "Latency stats fetched successfully",
+	})
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"http/internal/database"
+	"http/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CheckConfigController struct {
+	checkConfigService *service.CheckConfigService
+}
+
+func NewCheckConfigController(db *gorm.DB, api *gin.RouterGroup) *CheckConfigController {
+	checkConfigService, err := service.NewCheckConfigService(db)
+	if err != nil {
+		log.Fatalf("Failed to create check config service: %v", err)
+	}
+
+	cc := &CheckConfigController{
+		checkConfigService: checkConfigService,
+	}
+
+	api.GET("/products/:product_id/check-config", cc.getCheckConfig)
+	api.PUT("/products/:product_id/check-config", cc.updateCheckConfig)
+
+	return cc
+}
+
+func (cc *CheckConfigController) getCheckConfig(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	config, err := cc.checkConfigService.GetCheckConfig(uint(productID))
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch check config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    config,
+		"message": "Check config fetched successfully",
+	})
+}
+
+func (cc *CheckConfigController) updateCheckConfig(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var config database.CheckConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	updated, err := cc.checkConfigService.UpsertCheckConfig(uint(productID), config)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update check config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    updated,
+		"message": "Check config updated successfully",
+	})
+}
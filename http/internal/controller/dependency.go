@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"http/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DependencyController struct {
+	dependencyService *service.DependencyService
+}
+
+type setDependenciesRequest struct {
+	DependsOn []uint `json:"depends_on"`
+}
+
+func NewDependencyController(db *gorm.DB, api *gin.RouterGroup) *DependencyController {
+	dependencyService, err := service.NewDependencyService(db)
+	if err != nil {
+		log.Fatalf("Failed to create dependency service: %v", err)
+	}
+
+	d := &DependencyController{
+		dependencyService: dependencyService,
+	}
+
+	api.GET("/products/:product_id/dependencies", d.getDependencies)
+	api.PUT("/products/:product_id/dependencies", d.setDependencies)
+
+	return d
+}
+
+func (d *DependencyController) getDependencies(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	dependencies, err := d.dependencyService.GetDependencies(uint(productID))
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch dependencies",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    dependencies,
+		"message": "Dependencies fetched successfully",
+	})
+}
+
+func (d *DependencyController) setDependencies(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var request setDependenciesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	dependencies, err := d.dependencyService.SetDependencies(uint(productID), request.DependsOn)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update dependencies",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    dependencies,
+		"message": "Dependencies updated successfully",
+	})
+}
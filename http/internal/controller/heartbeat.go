@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"http/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HeartbeatController struct {
+	heartbeatService *service.HeartbeatService
+}
+
+// NewHeartbeatController registers the heartbeat URLs outside the /api group;
+// cron jobs and workers authenticate with the token in the path only.
+func NewHeartbeatController(db *gorm.DB, r *gin.Engine) *HeartbeatController {
+	heartbeatService, err := service.NewHeartbeatService(db)
+	if err != nil {
+		log.Fatalf("Failed to create heartbeat service: %v", err)
+	}
+
+	h := &HeartbeatController{
+		heartbeatService: heartbeatService,
+	}
+
+	r.GET("/heartbeat/:token", h.recordHeartbeat)
+	r.POST("/heartbeat/:token", h.recordHeartbeat)
+
+	return h
+}
+
+func (h *HeartbeatController) recordHeartbeat(c *gin.Context) {
+	if err := h.heartbeatService.RecordHeartbeat(c.Param("token")); err != nil {
+		if err.Error() == "heartbeat not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Heartbeat not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record heartbeat",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Heartbeat received",
+	})
+}
@@ -54,6 +54,7 @@ func (l *LogsController) getLogs(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "50")
 	pageStr := c.DefaultQuery("page", "1")
 	level := c.Query("level")
+	traceID := c.Query("trace_id")
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 
@@ -67,7 +68,7 @@ func (l *LogsController) getLogs(c *gin.Context) {
 		page = 1
 	}
 
-	logs, total, err := l.logsService.GetLogs(uint(productID), limit, page, level, startDate, endDate)
+	logs, total, err := l.logsService.GetLogs(uint(productID), limit, page, level, traceID, startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch logs",
@@ -105,6 +106,7 @@ func (l *LogsController) getProductLogs(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "50")
 	pageStr := c.DefaultQuery("page", "1")
 	level := c.Query("level")
+	traceID := c.Query("trace_id")
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 
@@ -118,7 +120,7 @@ func (l *LogsController) getProductLogs(c *gin.Context) {
 		page = 1
 	}
 
-	logs, total, err := l.logsService.GetLogs(uint(productID), limit, page, level, startDate, endDate)
+	logs, total, err := l.logsService.GetLogs(uint(productID), limit, page, level, traceID, startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch logs",
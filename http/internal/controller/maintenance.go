@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"http/internal/database"
+	"http/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MaintenanceController struct {
+	maintenanceService *service.MaintenanceService
+}
+
+func NewMaintenanceController(db *gorm.DB, api *gin.RouterGroup) *MaintenanceController {
+	maintenanceService, err := service.NewMaintenanceService(db)
+	if err != nil {
+		log.Fatalf("Failed to create maintenance service: %v", err)
+	}
+
+	m := &MaintenanceController{
+		maintenanceService: maintenanceService,
+	}
+
+	api.GET("/products/:product_id/maintenance-windows", m.getMaintenanceWindows)
+	api.POST("/products/:product_id/maintenance-windows", m.createMaintenanceWindow)
+	api.PUT("/products/:product_id/maintenance-windows/:window_id", m.updateMaintenanceWindow)
+	api.DELETE("/products/:product_id/maintenance-windows/:window_id", m.deleteMaintenanceWindow)
+
+	return m
+}
+
+func (m *MaintenanceController) getMaintenanceWindows(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	windows, err := m.maintenanceService.GetMaintenanceWindows(uint(productID))
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch maintenance windows",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    windows,
+		"count":   len(windows),
+		"message": "Maintenance windows fetched successfully",
+	})
+}
+
+func (m *MaintenanceController) createMaintenanceWindow(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var window database.MaintenanceWindow
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	created, err := m.maintenanceService.CreateMaintenanceWindow(uint(productID), window)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create maintenance window",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    created,
+		"message": "Maintenance window created successfully",
+	})
+}
+
+func (m *MaintenanceController) updateMaintenanceWindow(c *gin.Context) {
+	productID, windowID, ok := parseMaintenanceIDs(c)
+	if !ok {
+		return
+	}
+
+	var window database.MaintenanceWindow
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	updated, err := m.maintenanceService.UpdateMaintenanceWindow(productID, windowID, window)
+	if err != nil {
+		if err.Error() == "maintenance window not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Maintenance window not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update maintenance window",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    updated,
+		"message": "Maintenance window updated successfully",
+	})
+}
+
+func (m *MaintenanceController) deleteMaintenanceWindow(c *gin.Context) {
+	productID, windowID, ok := parseMaintenanceIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := m.maintenanceService.DeleteMaintenanceWindow(productID, windowID); err != nil {
+		if err.Error() == "maintenance window not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Maintenance window not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete maintenance window",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Maintenance window deleted successfully",
+	})
+}
+
+func parseMaintenanceIDs(c *gin.Context) (uint, uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid product ID",
+			"message": err.Error(),
+		})
+		return 0, 0, false
+	}
+	windowID, err := strconv.ParseUint(c.Param("window_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid maintenance window ID",
+			"message": err.Error(),
+		})
+		return 0, 0, false
+	}
+	return uint(productID), uint(windowID), true
+}
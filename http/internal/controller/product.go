@@ -123,7 +123,7 @@ func (p *ProductController) createProduct(c *gin.Context) {
 func (p *ProductController) updateProduct(c *gin.Context) {
 	productID := c.Param("product_id")
 
-	var updatedProduct database.Product
+	var updatedProduct service.ProductUpdate
 	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request body",
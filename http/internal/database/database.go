@@ -96,5 +96,5 @@ func (s *service) Close() error {
 }
 
 func (s *service) migrate() error {
-	return s.db.AutoMigrate(&User{}, &Product{}, &Log{})
+	return s.db.AutoMigrate(&User{}, &Agent{}, &Product{}, &Log{}, &CheckConfig{}, &CheckAssertion{}, &CheckHeader{}, &CheckStep{}, &MaintenanceWindow{}, &Heartbeat{}, &ProductDependency{})
 }
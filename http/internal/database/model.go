@@ -21,34 +21,221 @@ type User struct {
 }
 
 type Product struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name        string    `gorm:"size:255;not null" json:"name"`
-	Description string    `gorm:"type:text" json:"description"`
-	UserID      uint      `gorm:"not null" json:"user_id"`
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string       `gorm:"size:255;not null" json:"name"`
+	Description string       `gorm:"type:text" json:"description"`
+	UserID      uint         `gorm:"not null" json:"user_id"`
+	CreatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	User        User         `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
+	AuthToken   uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4()" json:"auth_token"`
+	HealthAPI   string       `gorm:"type:text" json:"health_api"`
+	AgentID     *uint        `gorm:"index" json:"agent_id,omitempty"` // checked by a remote agent instead of the ping-service
+	Logs        []Log        `gorm:"constraint:OnDelete:CASCADE;"`
+	CheckConfig *CheckConfig `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"check_config,omitempty"`
+}
+
+type CheckConfig struct {
+	ID                  uint             `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID           uint             `gorm:"not null;uniqueIndex" json:"product_id"`
+	CheckType           string           `gorm:"size:20;not null;default:'http'" json:"check_type"` // "http", "tcp", "tls", "dns", "grpc", "heartbeat", "synthetic"
+	IntervalMs          int              `gorm:"not null;default:10000" json:"interval_ms"`
+	TimeoutMs           int              `gorm:"not null;default:30000" json:"timeout_ms"`
+	ExpectedStatusCodes string           `gorm:"size:255;not null;default:'200'" json:"expected_status_codes"`
+	MaxRetries          int              `gorm:"not null;default:3" json:"max_retries"`
+	BackoffBaseMs       int              `gorm:"not null;default:2000" json:"backoff_base_ms"`
+	DNSRecordType       string           `gorm:"size:10" json:"dns_record_type,omitempty"`
+	DNSExpected         string           `gorm:"size:255" json:"dns_expected,omitempty"`
+	DNSResolver         string           `gorm:"size:255" json:"dns_resolver,omitempty"`
+	DegradedThresholdMs int              `gorm:"not null;default:0" json:"degraded_threshold_ms"` // 0 disables degraded detection
+	DegradedAfter       int              `gorm:"not null;default:3" json:"degraded_after"`
+	Quorum              int              `gorm:"not null;default:1" json:"quorum"`         // probe locations that must agree before a downtime opens
+	FlapThreshold       int              `gorm:"not null;default:5" json:"flap_threshold"` // up/down changes within FlapWindowMs that mark the product flapping
+	FlapWindowMs        int              `gorm:"not null;default:600000" json:"flap_window_ms"`
+	HeartbeatToken      *string          `gorm:"size:64;uniqueIndex" json:"heartbeat_token,omitempty"` // identifies the heartbeat URL of "heartbeat" checks
+	HeartbeatPeriodMs   int              `gorm:"not null;default:0" json:"heartbeat_period_ms,omitempty"`
+	HeartbeatGraceMs    int              `gorm:"not null;default:0" json:"heartbeat_grace_ms,omitempty"`
+	Method              string           `gorm:"size:10;not null;default:'GET'" json:"method"`
+	RequestBody         string           `gorm:"type:text" json:"request_body,omitempty"`
+	AuthType            string           `gorm:"size:20" json:"auth_type,omitempty"` // "", "basic", "bearer"
+	AuthUsername        string           `gorm:"size:255" json:"auth_username,omitempty"`
+	AuthSecret          string           `gorm:"-" json:"auth_secret,omitempty"` // write-only; stored in AuthSecretEncrypted
+	AuthSecretEncrypted string           `gorm:"type:text" json:"-"`
+	Headers             []CheckHeader    `gorm:"foreignKey:CheckConfigID;constraint:OnDelete:CASCADE;" json:"headers,omitempty"`
+	TLSClientCert       string           `gorm:"column:tls_client_cert;type:text" json:"tls_client_cert,omitempty"` // PEM, for servers that require mutual TLS
+	TLSClientKey        string           `gorm:"-" json:"tls_client_key,omitempty"`                                 // write-only; stored in TLSKeyEncrypted
+	TLSKeyEncrypted     string           `gorm:"column:tls_key_encrypted;type:text" json:"-"`
+	TLSCABundle         string           `gorm:"column:tls_ca_bundle;type:text" json:"tls_ca_bundle,omitempty"` // PEM roots trusted instead of the system pool
+	TLSSkipVerify       bool             `gorm:"column:tls_skip_verify;not null;default:false" json:"tls_skip_verify"`
+	RedirectPolicy      string           `gorm:"size:20;not null;default:'follow'" json:"redirect_policy"` // "follow", "none"
+	MaxRedirects        int              `gorm:"not null;default:10" json:"max_redirects"`
+	GRPCService         string           `gorm:"column:grpc_service;size:255" json:"grpc_service,omitempty"` // service name sent to grpc.health.v1; empty checks the whole server
+	Assertions          []CheckAssertion `gorm:"foreignKey:CheckConfigID;constraint:OnDelete:CASCADE;" json:"assertions,omitempty"`
+	Steps               []CheckStep      `gorm:"foreignKey:CheckConfigID;constraint:OnDelete:CASCADE;" json:"steps,omitempty"` // requests of "synthetic" checks, by Position
+	CreatedAt           time.Time        `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt           time.Time        `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type CheckAssertion struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	CheckConfigID uint   `gorm:"not null;index" json:"check_config_id"`
+	Type          string `gorm:"size:50;not null" json:"type"` // "contains", "not_contains", "regex", "json_path"
+	Path          string `gorm:"size:255" json:"path,omitempty"`
+	Operator      string `gorm:"size:20" json:"operator,omitempty"` // "eq", "ne", "gt", "gte", "lt", "lte"
+	Value         string `gorm:"type:text;not null" json:"value"`
+}
+
+// CheckStep is one request of a synthetic check. Values extracted from the
+// JSON responses of earlier steps are available as {{name}} in the URL,
+// headers and body of later steps.
+type CheckStep struct {
+	ID                  uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	CheckConfigID       uint              `gorm:"not null;index" json:"check_config_id"`
+	Position            int               `gorm:"not null" json:"position"`
+	Name                string            `gorm:"size:255;not null" json:"name"`
+	Method              string            `gorm:"size:10;not null;default:'GET'" json:"method"`
+	URL                 string            `gorm:"type:text;not null" json:"url"`
+	Headers             map[string]string `gorm:"serializer:json;type:jsonb" json:"headers,omitempty"`
+	Body                string            `gorm:"type:text" json:"body,omitempty"`
+	ExpectedStatusCodes string            `gorm:"size:255;not null;default:'200'" json:"expected_status_codes"`
+	TimeoutMs           int               `gorm:"not null;default:10000" json:"timeout_ms"`
+	Extract             map[string]string `gorm:"serializer:json;type:jsonb" json:"extract,omitempty"` // variable name -> JSON path, e.g. "token": "$.data.token"
+	Assertions          []StepAssertion   `gorm:"serializer:json;type:jsonb" json:"assertions,omitempty"`
+}
+
+// StepAssertion has the same meaning as a CheckAssertion, applied to the
+// response of a single step.
+type StepAssertion struct {
+	Type     string `json:"type"`
+	Path     string `json:"path,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value"`
+}
+
+// CheckHeader is a request header sent with http checks. The value of a
+// secret header is write-only and stored in EncryptedValue.
+type CheckHeader struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	CheckConfigID  uint   `gorm:"not null;index" json:"check_config_id"`
+	Name           string `gorm:"size:255;not null" json:"name"`
+	Value          string `gorm:"type:text" json:"value,omitempty"`
+	Secret         bool   `gorm:"not null;default:false" json:"secret"`
+	EncryptedValue string `gorm:"type:text" json:"-"`
+}
+
+// CheckResult rows are written by the ping-service, which also owns the
+// check_results hypertable; the API only reads them.
+type CheckResult struct {
+	ProductID  uint              `json:"product_id"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Success    bool              `json:"success"`
+	StatusCode int               `json:"status_code,omitempty"`
+	DNSMs      float64           `gorm:"column:dns_ms" json:"dns_ms"`
+	ConnectMs  float64           `json:"connect_ms"`
+	TLSMs      float64           `gorm:"column:tls_ms" json:"tls_ms"`
+	TTFBMs     float64           `gorm:"column:ttfb_ms" json:"ttfb_ms"`
+	TotalMs    float64           `json:"total_ms"`
+	Location   string            `json:"location,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Components []ComponentStatus `gorm:"serializer:json" json:"components,omitempty"`
+}
+
+// ComponentStatus is the state of one dependency of a product as reported
+// by its health endpoint, e.g. the database behind an API.
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "pass", "warn", "fail"
+	Output string `json:"output,omitempty"`
+}
+
+// Agent is a remote probe that checks the products assigned to it from
+// inside a private network. Only the SHA-256 of its token is stored.
+type Agent struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:255;not null" json:"name"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// MaintenanceWindow is planned downtime for a product. A one-off window
+// covers StartTime to EndTime; a recurring one starts whenever the cron
+// Schedule fires in Timezone and lasts DurationMinutes.
+type MaintenanceWindow struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID       uint       `gorm:"not null;index" json:"product_id"`
+	Product         Product    `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"-"`
+	Name            string     `gorm:"size:255" json:"name"`
+	StartTime       *time.Time `json:"start_time,omitempty"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	Schedule        string     `gorm:"size:100" json:"schedule,omitempty"` // e.g. "0 2 * * 0" for Sundays at 02:00
+	DurationMinutes int        `gorm:"not null;default:0" json:"duration_minutes,omitempty"`
+	Timezone        string     `gorm:"size:64;not null;default:'UTC'" json:"timezone"`
+	CreatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// Heartbeat is the last heartbeat a product's job sent to its heartbeat URL.
+type Heartbeat struct {
+	ProductID  uint      `gorm:"primaryKey;autoIncrement:false" json:"product_id"`
+	Product    Product   `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"-"`
+	ReceivedAt time.Time `gorm:"not null" json:"received_at"`
+}
+
+// ProductDependency records that ProductID depends on DependsOnID, e.g. an
+// API on its database. While the upstream product is down, downtimes of its
+// dependents are attributed to its incident instead of alerting on their own.
+type ProductDependency struct {
+	ProductID   uint      `gorm:"primaryKey;autoIncrement:false" json:"product_id"`
+	Product     Product   `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"-"`
+	DependsOnID uint      `gorm:"primaryKey;autoIncrement:false;index" json:"depends_on_id"`
+	DependsOn   Product   `gorm:"foreignKey:DependsOnID;constraint:OnDelete:CASCADE;" json:"-"`
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	User        User      `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
-	AuthToken   uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()" json:"auth_token"`
-	HealthAPI   string    `gorm:"type:text" json:"health_api"`
-	Logs        []Log     `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
+// Log is a log entry of a product. Entries sent through the v1 ingestion
+// API only have LogData and Timestamp.
 type Log struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID uint      `gorm:"not null;index" json:"product_id"`
-	Product   Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	LogData   string    `gorm:"type:text;not null" json:"log_data"`
-	Timestamp time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID         uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID  uint              `gorm:"not null;index" json:"product_id"`
+	Product    Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	LogData    string            `gorm:"type:text;not null" json:"log_data"`
+	Level      string            `gorm:"size:10;index" json:"level,omitempty"` // "trace", "debug", "info", "warn", "error", "fatal"
+	Attributes map[string]string `gorm:"serializer:json;type:jsonb" json:"attributes,omitempty"`
+	Logger     string            `gorm:"size:255" json:"logger,omitempty"`
+	Hostname   string            `gorm:"size:255" json:"hostname,omitempty"`
+	Instance   string            `gorm:"size:255" json:"instance,omitempty"`
+	TraceID    string            `gorm:"size:32;index" json:"trace_id,omitempty"`
+	SpanID     string            `gorm:"size:16" json:"span_id,omitempty"`
+	Timestamp  time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 type Downtime struct {
-	ID                 uint              `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID          uint              `gorm:"not null;index" json:"product_id"`
-	Product            Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	StartTime          time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"start_time"`
-	EndTime            *time.Time        `json:"end_time,omitempty"`
-	Status             string            `gorm:"size:50;not null;default:'down'" json:"status"`
-	IsNotificationSent bool              `gorm:"not null;default:false" json:"is_notification_sent"`
-	QuickFixes         []ProductQuickFix `gorm:"constraint:OnDelete:CASCADE;" json:"quick_fixes,omitempty"`
+	ID                 uint                     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID          uint                     `gorm:"not null;index" json:"product_id"`
+	Product            Product                  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	StartTime          time.Time                `gorm:"not null;default:CURRENT_TIMESTAMP" json:"start_time"`
+	EndTime            *time.Time               `json:"end_time,omitempty"`
+	Status             string                   `gorm:"size:50;not null;default:'down'" json:"status"`
+	IncidentType       string                   `gorm:"size:20;not null;default:'down';index" json:"incident_type"` // "down", "degraded", "flapping"
+	IsNotificationSent bool                     `gorm:"not null;default:false" json:"is_notification_sent"`
+	FailureReason      string                   `gorm:"type:text" json:"failure_reason,omitempty"`
+	Components         []ComponentStatus        `gorm:"serializer:json;type:jsonb" json:"components,omitempty"` // health document of the check that opened it
+	CauseDowntimeID    *uint                    `gorm:"index" json:"cause_downtime_id,omitempty"`               // upstream incident this downtime is attributed to
+	QuickFixes         []ProductQuickFix        `gorm:"constraint:OnDelete:CASCADE;" json:"quick_fixes,omitempty"`
+	LocationResults    []DowntimeLocationResult `gorm:"foreignKey:DowntimeID;constraint:OnDelete:CASCADE;" json:"location_results,omitempty"`
+}
+
+// DowntimeLocationResult is the latest verdict of one probe location while
+// a downtime is open.
+type DowntimeLocationResult struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DowntimeID uint      `gorm:"not null;uniqueIndex:idx_downtime_location" json:"downtime_id"`
+	Location   string    `gorm:"size:100;not null;uniqueIndex:idx_downtime_location" json:"location"`
+	Success    bool      `gorm:"not null" json:"success"`
+	Reason     string    `gorm:"type:text" json:"reason,omitempty"`
+	CheckedAt  time.Time `gorm:"not null" json:"checked_at"`
 }
 
 type ProductQuickFix struct {
@@ -75,3 +262,23 @@ func (Log) TableName() string { return "logs" }
 func (Downtime) TableName() string { return "downtimes" }
 
 func (ProductQuickFix) TableName() string { return "quick_fixes" }
+
+func (CheckConfig) TableName() string { return "check_configs" }
+
+func (CheckAssertion) TableName() string { return "check_assertions" }
+
+func (CheckHeader) TableName() string { return "check_headers" }
+
+func (CheckStep) TableName() string { return "check_steps" }
+
+func (CheckResult) TableName() string { return "check_results" }
+
+func (DowntimeLocationResult) TableName() string { return "downtime_location_results" }
+
+func (Agent) TableName() string { return "agents" }
+
+func (MaintenanceWindow) TableName() string { return "maintenance_windows" }
+
+func (Heartbeat) TableName() string { return "heartbeats" }
+
+func (ProductDependency) TableName() string { return "product_dependencies" }
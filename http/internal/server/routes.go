@@ -34,9 +34,14 @@ func (s *Server) RegisterRoutes() http.Handler {
 		controller.NewDowntimeController(db, api)
 		controller.NewAnalyticsController(db, api)
 		controller.NewQuickFixesController(db, api)
+		controller.NewCheckConfigController(db, api)
+		controller.NewAgentController(db, api)
+		controller.NewMaintenanceController(db, api)
+		controller.NewDependencyController(db, api)
 	}
 
 	controller.NewAuthController(db, r)
+	controller.NewHeartbeatController(db, r)
 
 	return r
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"http/internal/database"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AgentTokenPrefix makes agent tokens recognisable in configs and logs.
+const AgentTokenPrefix = "oba_"
+
+type AgentService struct {
+	db *gorm.DB
+}
+
+func NewAgentService(db *gorm.DB) (*AgentService, error) {
+	if db == nil {
+		return nil, errors.New("database connection cannot be nil")
+	}
+	return &AgentService{
+		db: db,
+	}, nil
+}
+
+// CreateAgent registers an agent and returns it together with its token.
+// The token is only stored hashed, so this is the only time it is visible.
+func (s *AgentService) CreateAgent(userID uint, name string) (*database.Agent, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("agent name is required")
+	}
+	if userID == 0 {
+		return nil, "", errors.New("user ID is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := AgentTokenPrefix + hex.EncodeToString(secret)
+
+	agent := database.Agent{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAgentToken(token),
+	}
+	if err := s.db.Create(&agent).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &agent, token, nil
+}
+
+func (s *AgentService) GetAgentsByUser(userID uint) ([]database.Agent, error) {
+	var agents []database.Agent
+	if err := s.db.Where("user_id = ?", userID).Order("created_at").Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// DeleteAgent removes the agent and hands its products back to the
+// ping-service.
+func (s *AgentService) DeleteAgent(userID, agentID uint) error {
+	var agent database.Agent
+	if err := s.db.Where("id = ? AND user_id = ?", agentID, userID).First(&agent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("agent not found")
+		}
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var productIDs []uint
+		if err := tx.Model(&database.Product{}).Where("agent_id = ?", agent.ID).Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&database.Product{}).Where("agent_id = ?", agent.ID).
+			Updates(map[string]interface{}{"agent_id": nil, "updated_at": gorm.Expr("now()")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&agent).Error; err != nil {
+			return err
+		}
+		for _, productID := range productIDs {
+			if err := notifyProductChange(tx, productID, ProductChangeUpsert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// validateProductAgent checks that a product is assigned to an agent of the
+// product's owner.
+func validateProductAgent(db *gorm.DB, userID uint, agentID *uint) error {
+	if agentID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&database.Agent{}).Where("id = ? AND user_id = ?", *agentID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("agent not found")
+	}
+	return nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"http/internal/database"
 	"time"
 
@@ -12,12 +13,21 @@ type AnalyticsService struct {
 }
 
 type UptimeStats struct {
-	ProductID          uint    `json:"product_id"`
-	UptimePercentage   float64 `json:"uptime_percentage"`
-	TotalDowntimeMinutes int   `json:"total_downtime_minutes"`
-	IncidentCount      int     `json:"incident_count"`
-	PeriodStart        string  `json:"period_start"`
-	PeriodEnd          string  `json:"period_end"`
+	ProductID             uint    `json:"product_id"`
+	UptimePercentage      float64 `json:"uptime_percentage"`
+	TotalDowntimeMinutes  int     `json:"total_downtime_minutes"`
+	IncidentCount         int     `json:"incident_count"`
+	DegradedPercentage    float64 `json:"degraded_percentage"`
+	TotalDegradedMinutes  int     `json:"total_degraded_minutes"`
+	DegradedIncidentCount int     `json:"degraded_incident_count"`
+	FlappingIncidentCount int     `json:"flapping_incident_count"`
+	MaintenanceMinutes    int     `json:"maintenance_minutes"`
+	UpstreamMinutes       int     `json:"upstream_downtime_minutes"` // part of the downtime caused by products it depends on
+	UpstreamIncidentCount int     `json:"upstream_incident_count"`
+	CausedMinutes         int     `json:"caused_downtime_minutes"` // downtime of dependent products caused by its incidents
+	CausedIncidentCount   int     `json:"caused_incident_count"`
+	PeriodStart           string  `json:"period_start"`
+	PeriodEnd             string  `json:"period_end"`
 }
 
 func NewAnalyticsService(db *gorm.DB) (*AnalyticsService, error) {
@@ -27,23 +37,168 @@ func NewAnalyticsService(db *gorm.DB) (*AnalyticsService, error) {
 }
 
 func (s *AnalyticsService) GetUptimeStats(productID uint, period, startDate, endDate string) (*UptimeStats, error) {
-	var periodStart, periodEnd time.Time
 	now := time.Now()
+	periodStart, periodEnd, err := resolvePeriod(period, startDate, endDate, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get downtime incidents in the period
+	var downtimes []database.Downtime
+	if err := s.db.Where("product_id = ? AND start_time >= ? AND start_time <= ?", 
+		productID, periodStart, periodEnd).Find(&downtimes).Error; err != nil {
+		return nil, err
+	}
+
+	// Maintenance windows are planned, so they count neither as downtime
+	// nor towards the period
+	var windows []database.MaintenanceWindow
+	if err := s.db.Where("product_id = ?", productID).Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	maintenance := maintenanceRanges(windows, periodStart, periodEnd)
+
+	// Calculate total downtime minutes; degraded incidents are tracked
+	// separately and do not count against uptime
+	var totalDowntimeMinutes, totalDegradedMinutes, upstreamMinutes int
+	var incidentCount, degradedIncidentCount, flappingIncidentCount, upstreamIncidentCount int
+	var downtimeIDs []uint
+
+	for _, downtime := range downtimes {
+		minutes := incidentMinutes(downtime, periodStart, periodEnd, now, maintenance)
+
+		switch downtime.IncidentType {
+		case "degraded":
+			degradedIncidentCount++
+			totalDegradedMinutes += minutes
+		case "flapping":
+			// The downtimes recorded while flapping are counted on their own
+			flappingIncidentCount++
+		default:
+			incidentCount++
+			totalDowntimeMinutes += minutes
+			downtimeIDs = append(downtimeIDs, downtime.ID)
+			// Still downtime for the product, but attributed to the
+			// incident of a product it depends on
+			if downtime.CauseDowntimeID != nil {
+				upstreamIncidentCount++
+				upstreamMinutes += minutes
+			}
+		}
+	}
+
+	// Downtime of dependent products attributed to this product's incidents
+	var causedMinutes, causedIncidentCount int
+	if len(downtimeIDs) > 0 {
+		var caused []database.Downtime
+		if err := s.db.Where("cause_downtime_id IN ? AND start_time <= ?", downtimeIDs, periodEnd).
+			Find(&caused).Error; err != nil {
+			return nil, err
+		}
+		for _, downtime := range caused {
+			causedIncidentCount++
+			causedMinutes += incidentMinutes(downtime, periodStart, periodEnd, now, nil)
+		}
+	}
+
+	// Calculate uptime percentage
+	maintenanceDuration := overlap(periodStart, periodEnd, maintenance)
+	totalPeriodMinutes := int((periodEnd.Sub(periodStart) - maintenanceDuration).Minutes())
+	uptimeMinutes := totalPeriodMinutes - totalDowntimeMinutes
+	uptimePercentage := 100.0
+	if totalPeriodMinutes > 0 {
+		uptimePercentage = float64(uptimeMinutes) / float64(totalPeriodMinutes) * 100
+	}
+
+	// Ensure uptime percentage is between 0 and 100
+	if uptimePercentage < 0 {
+		uptimePercentage = 0
+	}
+	if uptimePercentage > 100 {
+		uptimePercentage = 100
+	}
+
+	var degradedPercentage float64
+	if totalPeriodMinutes > 0 {
+		degradedPercentage = float64(totalDegradedMinutes) / float64(totalPeriodMinutes) * 100
+		if degradedPercentage > 100 {
+			degradedPercentage = 100
+		}
+	}
+
+	return &UptimeStats{
+		ProductID:             productID,
+		UptimePercentage:      uptimePercentage,
+		TotalDowntimeMinutes:  totalDowntimeMinutes,
+		IncidentCount:         incidentCount,
+		DegradedPercentage:    degradedPercentage,
+		TotalDegradedMinutes:  totalDegradedMinutes,
+		DegradedIncidentCount: degradedIncidentCount,
+		FlappingIncidentCount: flappingIncidentCount,
+		MaintenanceMinutes:    int(maintenanceDuration.Minutes()),
+		UpstreamMinutes:       upstreamMinutes,
+		UpstreamIncidentCount: upstreamIncidentCount,
+		CausedMinutes:         causedMinutes,
+		CausedIncidentCount:   causedIncidentCount,
+		PeriodStart:           periodStart.Format(time.RFC3339),
+		PeriodEnd:             periodEnd.Format(time.RFC3339),
+	}, nil
+}
+
+// incidentMinutes returns how long a downtime lasted within the period,
+// excluding maintenance. Ongoing downtimes count until now.
+func incidentMinutes(downtime database.Downtime, periodStart, periodEnd, now time.Time, maintenance []timeRange) int {
+	endTime := now
+	if downtime.EndTime != nil {
+		endTime = *downtime.EndTime
+	}
+
+	// Only count downtime within our period
+	startTime := downtime.StartTime
+	if startTime.Before(periodStart) {
+		startTime = periodStart
+	}
+	if endTime.After(periodEnd) {
+		endTime = periodEnd
+	}
+
+	if !endTime.After(startTime) {
+		return 0
+	}
+	duration := endTime.Sub(startTime) - overlap(startTime, endTime, maintenance)
+	return int(duration.Minutes())
+}
+
+// MaxCustomPeriod bounds the span of a custom start_date/end_date period.
+const MaxCustomPeriod = 366 * 24 * time.Hour
+
+func resolvePeriod(period, startDate, endDate string, now time.Time) (time.Time, time.Time, error) {
+	var periodStart, periodEnd time.Time
 
 	// Determine time period
 	if startDate != "" && endDate != "" {
 		var err error
 		periodStart, err = time.Parse(time.RFC3339, startDate)
 		if err != nil {
-			return nil, err
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: start_date: %w", err)
 		}
 		periodEnd, err = time.Parse(time.RFC3339, endDate)
 		if err != nil {
-			return nil, err
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: end_date: %w", err)
+		}
+		if !periodEnd.After(periodStart) {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: end_date must be after start_date")
+		}
+		if periodEnd.Sub(periodStart) > MaxCustomPeriod {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: it cannot span more than %d days", MaxCustomPeriod/(24*time.Hour))
 		}
 	} else {
 		// Use predefined periods
 		switch period {
+		case "1h":
+			periodStart = now.Add(-time.Hour)
+		case "6h":
+			periodStart = now.Add(-6 * time.Hour)
 		case "24h":
 			periodStart = now.Add(-24 * time.Hour)
 		case "7d":
@@ -58,60 +213,167 @@ func (s *AnalyticsService) GetUptimeStats(productID uint, period, startDate, end
 		periodEnd = now
 	}
 
-	// Get downtime incidents in the period
-	var downtimes []database.Downtime
-	if err := s.db.Where("product_id = ? AND start_time >= ? AND start_time <= ?", 
-		productID, periodStart, periodEnd).Find(&downtimes).Error; err != nil {
-		return nil, err
-	}
+	return periodStart, periodEnd, nil
+}
 
-	// Calculate total downtime minutes
-	var totalDowntimeMinutes int
-	incidentCount := len(downtimes)
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
 
-	for _, downtime := range downtimes {
-		var endTime time.Time
-		if downtime.EndTime != nil {
-			endTime = *downtime.EndTime
-		} else {
-			// If incident is ongoing, use current time
-			endTime = now
-		}
+type LatencyBucket struct {
+	Bucket       time.Time `json:"bucket"`
+	CheckCount   int       `json:"check_count"`
+	SuccessRatio float64   `json:"success_ratio"`
+	LatencyPercentiles
+}
 
-		// Only count downtime within our period
-		startTime := downtime.StartTime
-		if startTime.Before(periodStart) {
-			startTime = periodStart
-		}
-		if endTime.After(periodEnd) {
-			endTime = periodEnd
-		}
+type LatencyStats struct {
+	ProductID    uint               `json:"product_id"`
+	Interval     string             `json:"interval"`
+	CheckCount   int                `json:"check_count"`
+	SuccessRatio float64            `json:"success_ratio"`
+	Overall      LatencyPercentiles `json:"overall"`
+	Buckets      []LatencyBucket    `json:"buckets"`
+	PeriodStart  string             `json:"period_start"`
+	PeriodEnd    string             `json:"period_end"`
+}
 
-		if endTime.After(startTime) {
-			duration := endTime.Sub(startTime)
-			totalDowntimeMinutes += int(duration.Minutes())
-		}
+// MaxLatencyBuckets caps the number of buckets a latency stats request may
+// return. Without an explicit interval a coarser one is picked instead.
+const MaxLatencyBuckets = 1000
+
+type latencyInterval struct {
+	bucket string
+	step   time.Duration
+}
+
+// latencyIntervals maps the accepted interval query values to Postgres
+// intervals for time_bucket.
+var latencyIntervals = map[string]latencyInterval{
+	"1m":  {"1 minute", time.Minute},
+	"5m":  {"5 minutes", 5 * time.Minute},
+	"15m": {"15 minutes", 15 * time.Minute},
+	"1h":  {"1 hour", time.Hour},
+	"6h":  {"6 hours", 6 * time.Hour},
+	"1d":  {"1 day", 24 * time.Hour},
+}
+
+// latencyBucketCount is the most buckets a span can touch: time_bucket
+// aligns buckets to fixed boundaries, which the span rarely starts on.
+func latencyBucketCount(span, step time.Duration) int64 {
+	return int64(span/step) + 1
+}
+
+// Percentiles are computed over successful checks only; failed checks
+// usually end at the timeout and would drown out real latency.
+const latencyAggregates = `count(*) AS check_count,
+	COALESCE(avg(CASE WHEN success THEN 1.0 ELSE 0.0 END), 0) AS success_ratio,
+	COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY total_ms) FILTER (WHERE success), 0) AS p50,
+	COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY total_ms) FILTER (WHERE success), 0) AS p90,
+	COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY total_ms) FILTER (WHERE success), 0) AS p99,
+	COALESCE(max(total_ms) FILTER (WHERE success), 0) AS max`
+
+func (s *AnalyticsService) GetLatencyStats(productID uint, period, interval, startDate, endDate string) (*LatencyStats, error) {
+	now := time.Now()
+	periodStart, periodEnd, err := resolvePeriod(period, startDate, endDate, now)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate uptime percentage
-	totalPeriodMinutes := int(periodEnd.Sub(periodStart).Minutes())
-	uptimeMinutes := totalPeriodMinutes - totalDowntimeMinutes
-	uptimePercentage := float64(uptimeMinutes) / float64(totalPeriodMinutes) * 100
+	span := periodEnd.Sub(periodStart)
+	explicit := interval != ""
+	if !explicit {
+		interval = defaultLatencyInterval(span)
+	}
+	bucketInterval, ok := latencyIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("invalid interval %q", interval)
+	}
+	if explicit && latencyBucketCount(span, bucketInterval.step) > MaxLatencyBuckets {
+		return nil, fmt.Errorf("invalid interval %q: the period would need more than %d buckets", interval, MaxLatencyBuckets)
+	}
 
-	// Ensure uptime percentage is between 0 and 100
-	if uptimePercentage < 0 {
-		uptimePercentage = 0
+	var rows []struct {
+		Bucket       time.Time
+		CheckCount   int
+		SuccessRatio float64
+		P50          float64
+		P90          float64
+		P99          float64
+		Max          float64
 	}
-	if uptimePercentage > 100 {
-		uptimePercentage = 100
+	if err := s.db.Model(&database.CheckResult{}).
+		Select("time_bucket(?::interval, timestamp) AS bucket, "+latencyAggregates, bucketInterval.bucket).
+		Where("product_id = ? AND timestamp >= ? AND timestamp <= ?", productID, periodStart, periodEnd).
+		Group("bucket").
+		Order("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
 	}
 
-	return &UptimeStats{
-		ProductID:            productID,
-		UptimePercentage:     uptimePercentage,
-		TotalDowntimeMinutes: totalDowntimeMinutes,
-		IncidentCount:        incidentCount,
-		PeriodStart:          periodStart.Format(time.RFC3339),
-		PeriodEnd:            periodEnd.Format(time.RFC3339),
+	var overall struct {
+		CheckCount   int
+		SuccessRatio float64
+		P50          float64
+		P90          float64
+		P99          float64
+		Max          float64
+	}
+	if err := s.db.Model(&database.CheckResult{}).
+		Select(latencyAggregates).
+		Where("product_id = ? AND timestamp >= ? AND timestamp <= ?", productID, periodStart, periodEnd).
+		Scan(&overall).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]LatencyBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = LatencyBucket{
+			Bucket:       row.Bucket,
+			CheckCount:   row.CheckCount,
+			SuccessRatio: row.SuccessRatio,
+			LatencyPercentiles: LatencyPercentiles{
+				P50: row.P50,
+				P90: row.P90,
+				P99: row.P99,
+				Max: row.Max,
+			},
+		}
+	}
+
+	return &LatencyStats{
+		ProductID:    productID,
+		Interval:     interval,
+		CheckCount:   overall.CheckCount,
+		SuccessRatio: overall.SuccessRatio,
+		Overall: LatencyPercentiles{
+			P50: overall.P50,
+			P90: overall.P90,
+			P99: overall.P99,
+			Max: overall.Max,
+		},
+		Buckets:     buckets,
+		PeriodStart: periodStart.Format(time.RFC3339),
+		PeriodEnd:   periodEnd.Format(time.RFC3339),
 	}, nil
-}
\ No newline at end of file
+}
+
+// defaultLatencyInterval keeps the number of buckets in a chartable range.
+// For every period resolvePeriod accepts that is at most MaxLatencyBuckets.
+func defaultLatencyInterval(span time.Duration) string {
+	switch {
+	case span <= 6*time.Hour:
+		return "1m"
+	case span <= 24*time.Hour:
+		return "15m"
+	case span <= 7*24*time.Hour:
+		return "1h"
+	case span <= 30*24*time.Hour:
+		return "6h"
+	default:
+		return "1d"
+	}
+}
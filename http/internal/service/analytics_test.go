@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolvePeriod(t *testing.T) {
+	now := mustParseTime(t, "2026-03-04T00:00:00Z")
+
+	tests := []struct {
+		name      string
+		period    string
+		startDate string
+		endDate   string
+		wantStart string
+		wantErr   bool
+	}{
+		{name: "predefined", period: "7d", wantStart: "2026-02-25T00:00:00Z"},
+		{name: "default", period: "bogus", wantStart: "2026-02-02T00:00:00Z"},
+		{name: "custom", startDate: "2026-01-01T00:00:00Z", endDate: "2026-02-01T00:00:00Z", wantStart: "2026-01-01T00:00:00Z"},
+		{name: "longest custom", startDate: "2025-01-01T00:00:00Z", endDate: "2026-01-02T00:00:00Z", wantStart: "2025-01-01T00:00:00Z"},
+		{name: "custom too long", startDate: "1976-01-01T00:00:00Z", endDate: "2026-01-01T00:00:00Z", wantErr: true},
+		{name: "custom reversed", startDate: "2026-02-01T00:00:00Z", endDate: "2026-01-01T00:00:00Z", wantErr: true},
+		{name: "custom empty", startDate: "2026-02-01T00:00:00Z", endDate: "2026-02-01T00:00:00Z", wantErr: true},
+		{name: "invalid start", startDate: "yesterday", endDate: "2026-02-01T00:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, _, err := resolvePeriod(tt.period, tt.startDate, tt.endDate, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePeriod error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !start.Equal(mustParseTime(t, tt.wantStart)) {
+				t.Fatalf("start = %v, want %v", start, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestDefaultLatencyIntervalStaysWithinBuckets(t *testing.T) {
+	for span := time.Minute; span <= MaxCustomPeriod; span += 17 * time.Minute {
+		interval := defaultLatencyInterval(span)
+		if count := latencyBucketCount(span, latencyIntervals[interval].step); count > MaxLatencyBuckets {
+			t.Fatalf("default interval %s for %v gives %d buckets", interval, span, count)
+		}
+	}
+}
+
+func TestLatencyBucketCount(t *testing.T) {
+	tests := []struct {
+		span time.Duration
+		step time.Duration
+		want int64
+	}{
+		{30 * time.Second, time.Minute, 1},
+		{time.Minute, time.Minute, 2},
+		{999 * time.Minute, time.Minute, MaxLatencyBuckets},
+		{1000 * time.Minute, time.Minute, MaxLatencyBuckets + 1},
+		{42 * 24 * time.Hour, time.Hour, 1009},
+	}
+	for _, tt := range tests {
+		if got := latencyBucketCount(tt.span, tt.step); got != tt.want {
+			t.Errorf("latencyBucketCount(%v, %v) = %d, want %d", tt.span, tt.step, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"http/internal/database"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultCheckIntervalMs    = 10000
+	DefaultCheckTimeoutMs     = 30000
+	DefaultExpectedStatus     = "200"
+	DefaultCheckMaxRetries    = 3
+	DefaultCheckBackoffBaseMs = 2000
+	DefaultCheckType          = "http"
+	DefaultDegradedAfter      = 3
+	DefaultQuorum             = 1
+	DefaultFlapThreshold      = 5
+	DefaultFlapWindowMs       = 10 * 60 * 1000
+	DefaultHeartbeatPeriodMs  = 24 * 60 * 60 * 1000
+	DefaultHeartbeatGraceMs   = 5 * 60 * 1000
+	DefaultCheckMethod        = "GET"
+	DefaultRedirectPolicy     = "follow"
+	DefaultMaxRedirects       = 10
+
+	MaxCheckHeaders = 20
+)
+
+type CheckConfigService struct {
+	db *gorm.DB
+}
+
+func NewCheckConfigService(db *gorm.DB) (*CheckConfigService, error) {
+	if db == nil {
+		return nil, errors.New("database connection cannot be nil")
+	}
+	return &CheckConfigService{
+		db: db,
+	}, nil
+}
+
+// GetCheckConfig returns the stored check config for a product, or the
+// defaults the ping-service applies when none has been configured.
+func (s *CheckConfigService) GetCheckConfig(productID uint) (*database.CheckConfig, error) {
+	if err := s.ensureProduct(productID); err != nil {
+		return nil, err
+	}
+
+	var config database.CheckConfig
+	err := s.db.Preload("Assertions").Preload("Headers").Preload("Steps", orderedSteps).Where("product_id = ?", productID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		config = database.CheckConfig{ProductID: productID}
+		ApplyCheckConfigDefaults(&config)
+		return &config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (s *CheckConfigService) UpsertCheckConfig(productID uint, updated database.CheckConfig) (*database.CheckConfig, error) {
+	if err := s.ensureProduct(productID); err != nil {
+		return nil, err
+	}
+
+	ApplyCheckConfigDefaults(&updated)
+	if err := ValidateCheckConfig(&updated); err != nil {
+		return nil, err
+	}
+
+	var config database.CheckConfig
+	err := s.db.Preload("Headers").Where("product_id = ?", productID).First(&config).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := sealCredentials(&updated, &config); err != nil {
+		return nil, err
+	}
+
+	config.ProductID = productID
+	config.CheckType = updated.CheckType
+	config.IntervalMs = updated.IntervalMs
+	config.TimeoutMs = updated.TimeoutMs
+	config.ExpectedStatusCodes = updated.ExpectedStatusCodes
+	config.MaxRetries = updated.MaxRetries
+	config.BackoffBaseMs = updated.BackoffBaseMs
+	config.DNSRecordType = updated.DNSRecordType
+	config.DNSExpected = updated.DNSExpected
+	config.DNSResolver = updated.DNSResolver
+	config.GRPCService = updated.GRPCService
+	config.DegradedThresholdMs = updated.DegradedThresholdMs
+	config.DegradedAfter = updated.DegradedAfter
+	config.Quorum = updated.Quorum
+	config.FlapThreshold = updated.FlapThreshold
+	config.FlapWindowMs = updated.FlapWindowMs
+	config.HeartbeatPeriodMs = updated.HeartbeatPeriodMs
+	config.HeartbeatGraceMs = updated.HeartbeatGraceMs
+	config.Method = updated.Method
+	config.RequestBody = updated.RequestBody
+	config.AuthType = updated.AuthType
+	config.AuthUsername = updated.AuthUsername
+	config.AuthSecretEncrypted = updated.AuthSecretEncrypted
+	config.TLSClientCert = updated.TLSClientCert
+	config.TLSKeyEncrypted = updated.TLSKeyEncrypted
+	config.TLSCABundle = updated.TLSCABundle
+	config.TLSSkipVerify = updated.TLSSkipVerify
+	config.RedirectPolicy = updated.RedirectPolicy
+	config.MaxRedirects = updated.MaxRedirects
+
+	if config.CheckType == CheckTypeHeartbeat {
+		var product database.Product
+		if err := s.db.Select("agent_id").First(&product, productID).Error; err != nil {
+			return nil, err
+		}
+		if product.AgentID != nil {
+			return nil, errors.New("heartbeat checks cannot be assigned to an agent")
+		}
+	}
+	if err := assignHeartbeatToken(&config); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Assertions", "Headers", "Steps").Save(&config).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("check_config_id = ?", config.ID).Delete(&database.CheckHeader{}).Error; err != nil {
+			return err
+		}
+		config.Headers = make([]database.CheckHeader, len(updated.Headers))
+		for i, header := range updated.Headers {
+			header.ID = 0
+			header.CheckConfigID = config.ID
+			config.Headers[i] = header
+		}
+		if len(config.Headers) > 0 {
+			if err := tx.Create(&config.Headers).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("check_config_id = ?", config.ID).Delete(&database.CheckStep{}).Error; err != nil {
+			return err
+		}
+		config.Steps = make([]database.CheckStep, len(updated.Steps))
+		for i, step := range updated.Steps {
+			step.ID = 0
+			step.CheckConfigID = config.ID
+			config.Steps[i] = step
+		}
+		if len(config.Steps) > 0 {
+			if err := tx.Create(&config.Steps).Error; err != nil {
+				return err
+			}
+		}
+
+		// Assertions are replaced as a set on every update
+		if err := tx.Where("check_config_id = ?", config.ID).Delete(&database.CheckAssertion{}).Error; err != nil {
+			return err
+		}
+		config.Assertions = make([]database.CheckAssertion, len(updated.Assertions))
+		for i, assertion := range updated.Assertions {
+			assertion.ID = 0
+			assertion.CheckConfigID = config.ID
+			config.Assertions[i] = assertion
+		}
+		if len(config.Assertions) > 0 {
+			if err := tx.Create(&config.Assertions).Error; err != nil {
+				return err
+			}
+		}
+		return notifyProductChange(tx, productID, ProductChangeUpsert)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (s *CheckConfigService) ensureProduct(productID uint) error {
+	var product database.Product
+	if err := s.db.Select("id").First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// ApplyCheckConfigDefaults fills zero fields with the ping-service defaults.
+func ApplyCheckConfigDefaults(config *database.CheckConfig) {
+	if config.CheckType == "" {
+		config.CheckType = DefaultCheckType
+	}
+	if config.IntervalMs == 0 {
+		config.IntervalMs = DefaultCheckIntervalMs
+	}
+	if config.TimeoutMs == 0 {
+		config.TimeoutMs = DefaultCheckTimeoutMs
+	}
+	if strings.TrimSpace(config.ExpectedStatusCodes) == "" {
+		config.ExpectedStatusCodes = DefaultExpectedStatus
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultCheckMaxRetries
+	}
+	if config.BackoffBaseMs == 0 {
+		config.BackoffBaseMs = DefaultCheckBackoffBaseMs
+	}
+	if config.DegradedAfter == 0 {
+		config.DegradedAfter = DefaultDegradedAfter
+	}
+	if config.Quorum == 0 {
+		config.Quorum = DefaultQuorum
+	}
+	if config.FlapThreshold == 0 {
+		config.FlapThreshold = DefaultFlapThreshold
+	}
+	if config.FlapWindowMs == 0 {
+		config.FlapWindowMs = DefaultFlapWindowMs
+	}
+	if config.Method == "" {
+		config.Method = DefaultCheckMethod
+	}
+	if config.RedirectPolicy == "" {
+		config.RedirectPolicy = DefaultRedirectPolicy
+	}
+	if config.MaxRedirects == 0 {
+		config.MaxRedirects = DefaultMaxRedirects
+	}
+	if config.CheckType == CheckTypeHeartbeat {
+		if config.HeartbeatPeriodMs == 0 {
+			config.HeartbeatPeriodMs = DefaultHeartbeatPeriodMs
+		}
+		if config.HeartbeatGraceMs == 0 {
+			config.HeartbeatGraceMs = DefaultHeartbeatGraceMs
+		}
+	}
+}
+
+func ValidateCheckConfig(config *database.CheckConfig) error {
+	switch config.CheckType {
+	case "http":
+	case "tcp", "tls", "dns", "grpc", CheckTypeHeartbeat, CheckTypeSynthetic:
+		if len(config.Assertions) > 0 {
+			return errors.New("assertions are only supported for http checks")
+		}
+	default:
+		return fmt.Errorf("unknown check_type %q", config.CheckType)
+	}
+	config.GRPCService = strings.TrimSpace(config.GRPCService)
+	if config.CheckType != "grpc" && config.GRPCService != "" {
+		return errors.New("grpc_service is only supported for grpc checks")
+	}
+	if config.CheckType == "dns" {
+		config.DNSRecordType = strings.ToUpper(strings.TrimSpace(config.DNSRecordType))
+		switch config.DNSRecordType {
+		case "", "A", "AAAA", "CNAME", "MX", "NS", "TXT", "SRV":
+		default:
+			return fmt.Errorf("unsupported dns_record_type %q", config.DNSRecordType)
+		}
+	}
+	if err := validateCheckRequest(config); err != nil {
+		return err
+	}
+	if err := validateCheckTLS(config); err != nil {
+		return err
+	}
+	if err := validateCheckSteps(config); err != nil {
+		return err
+	}
+	if config.CheckType == CheckTypeHeartbeat {
+		if config.HeartbeatPeriodMs < 60000 || config.HeartbeatPeriodMs > 31*24*60*60*1000 {
+			return errors.New("heartbeat_period_ms must be between 60000 and 2678400000")
+		}
+		if config.HeartbeatGraceMs < 0 || config.HeartbeatGraceMs > 7*24*60*60*1000 {
+			return errors.New("heartbeat_grace_ms must be between 0 and 604800000")
+		}
+	} else {
+		config.HeartbeatPeriodMs = 0
+		config.HeartbeatGraceMs = 0
+	}
+	if config.IntervalMs < 1000 || config.IntervalMs > 24*60*60*1000 {
+		return errors.New("interval_ms must be between 1000 and 86400000")
+	}
+	if config.TimeoutMs < 100 || config.TimeoutMs > 120000 {
+		return errors.New("timeout_ms must be between 100 and 120000")
+	}
+	if config.MaxRetries < 1 || config.MaxRetries > 10 {
+		return errors.New("max_retries must be between 1 and 10")
+	}
+	if config.BackoffBaseMs < 100 || config.BackoffBaseMs > 60000 {
+		return errors.New("backoff_base_ms must be between 100 and 60000")
+	}
+	if config.DegradedThresholdMs < 0 || config.DegradedThresholdMs >= config.TimeoutMs {
+		return errors.New("degraded_threshold_ms must be 0 (disabled) or less than timeout_ms")
+	}
+	if config.DegradedAfter < 1 || config.DegradedAfter > 100 {
+		return errors.New("degraded_after must be between 1 and 100")
+	}
+	if config.Quorum < 1 || config.Quorum > 20 {
+		return errors.New("quorum must be between 1 and 20")
+	}
+	if config.FlapThreshold < 2 || config.FlapThreshold > 100 {
+		return errors.New("flap_threshold must be between 2 and 100")
+	}
+	if config.FlapWindowMs < 60000 || config.FlapWindowMs > 24*60*60*1000 {
+		return errors.New("flap_window_ms must be between 60000 and 86400000")
+	}
+	if err := validateStatusCodes(config.ExpectedStatusCodes); err != nil {
+		return err
+	}
+	for i := range config.Assertions {
+		if err := validateAssertion(&config.Assertions[i]); err != nil {
+			return fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validateCheckRequest checks the method, body, headers and auth that http
+// checks send. grpc checks send the headers and auth as metadata, synthetic
+// checks send them with every step.
+func validateCheckRequest(config *database.CheckConfig) error {
+	config.Method = strings.ToUpper(strings.TrimSpace(config.Method))
+	config.AuthType = strings.ToLower(strings.TrimSpace(config.AuthType))
+
+	if config.CheckType != "http" && (config.Method != DefaultCheckMethod || config.RequestBody != "") {
+		return errors.New("method and request_body are only supported for http checks")
+	}
+	if config.CheckType != "http" && config.CheckType != "grpc" && config.CheckType != CheckTypeSynthetic {
+		if len(config.Headers) > 0 || config.AuthType != "" {
+			return errors.New("headers and auth are only supported for http and grpc checks")
+		}
+		return nil
+	}
+
+	switch config.Method {
+	case "GET", "HEAD":
+		if config.RequestBody != "" {
+			return fmt.Errorf("request_body cannot be sent with %s", config.Method)
+		}
+	case "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+	default:
+		return fmt.Errorf("unsupported method %q", config.Method)
+	}
+
+	switch config.AuthType {
+	case "":
+		config.AuthUsername = ""
+	case "basic":
+		if strings.TrimSpace(config.AuthUsername) == "" {
+			return errors.New("auth_username is required for basic auth")
+		}
+	case "bearer":
+		config.AuthUsername = ""
+	default:
+		return fmt.Errorf("unknown auth_type %q", config.AuthType)
+	}
+
+	if len(config.Headers) > MaxCheckHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxCheckHeaders)
+	}
+	for i := range config.Headers {
+		header := &config.Headers[i]
+		header.Name = strings.TrimSpace(header.Name)
+		if !validHeaderName(header.Name) {
+			return fmt.Errorf("invalid header name %q", header.Name)
+		}
+		if strings.ContainsAny(header.Value, "\r\n") {
+			return fmt.Errorf("header %s contains a line break", header.Name)
+		}
+		if config.AuthType != "" && strings.EqualFold(header.Name, "Authorization") {
+			return errors.New("the Authorization header cannot be combined with auth_type")
+		}
+	}
+	return nil
+}
+
+// validHeaderName accepts the token characters allowed in header names.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		isAlnum := (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isAlnum && !strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCheckTLS checks the TLS settings of http, tls, grpc and synthetic
+// checks and the redirect policy of http and synthetic checks.
+func validateCheckTLS(config *database.CheckConfig) error {
+	isHTTP := config.CheckType == "http" || config.CheckType == CheckTypeSynthetic
+	if !isHTTP && config.CheckType != "tls" && config.CheckType != "grpc" {
+		if config.TLSClientCert != "" || config.TLSClientKey != "" || config.TLSCABundle != "" || config.TLSSkipVerify {
+			return errors.New("tls settings are only supported for http, tls, grpc and synthetic checks")
+		}
+	}
+	if !isHTTP && (config.RedirectPolicy != DefaultRedirectPolicy || config.MaxRedirects != DefaultMaxRedirects) {
+		return errors.New("redirect settings are only supported for http and synthetic checks")
+	}
+
+	switch config.RedirectPolicy {
+	case "follow", "none":
+	default:
+		return fmt.Errorf("unknown redirect_policy %q", config.RedirectPolicy)
+	}
+	if config.MaxRedirects < 1 || config.MaxRedirects > 20 {
+		return errors.New("max_redirects must be between 1 and 20")
+	}
+
+	if config.TLSCABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(config.TLSCABundle)) {
+		return errors.New("tls_ca_bundle contains no PEM certificates")
+	}
+	if config.TLSClientKey != "" && config.TLSClientCert == "" {
+		return errors.New("tls_client_key requires tls_client_cert")
+	}
+	return nil
+}
+
+// sealCredentials encrypts the write-only auth secret, client key and secret
+// header values of config in place. A secret that is left empty keeps its value
+// from previous, so a config can be updated without resending it.
+func sealCredentials(config *database.CheckConfig, previous *database.CheckConfig) error {
+	stored := make(map[string]string)
+	if previous != nil {
+		for _, header := range previous.Headers {
+			if header.Secret {
+				stored[strings.ToLower(header.Name)] = header.EncryptedValue
+			}
+		}
+	}
+
+	for i := range config.Headers {
+		header := &config.Headers[i]
+		header.EncryptedValue = ""
+		if !header.Secret {
+			continue
+		}
+		if header.Value != "" {
+			encrypted, err := encryptSecret(header.Value)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt header %s: %w", header.Name, err)
+			}
+			header.EncryptedValue = encrypted
+		} else if encrypted, ok := stored[strings.ToLower(header.Name)]; ok {
+			header.EncryptedValue = encrypted
+		} else {
+			return fmt.Errorf("secret header %s requires a value", header.Name)
+		}
+		header.Value = ""
+	}
+
+	switch {
+	case config.AuthType == "":
+		config.AuthSecretEncrypted = ""
+	case config.AuthSecret != "":
+		encrypted, err := encryptSecret(config.AuthSecret)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt auth_secret: %w", err)
+		}
+		config.AuthSecretEncrypted = encrypted
+	case previous != nil && previous.AuthType == config.AuthType && previous.AuthSecretEncrypted != "":
+		config.AuthSecretEncrypted = previous.AuthSecretEncrypted
+	default:
+		return errors.New("auth_secret is required")
+	}
+	config.AuthSecret = ""
+
+	switch {
+	case config.TLSClientCert == "":
+		config.TLSKeyEncrypted = ""
+	case config.TLSClientKey != "":
+		if _, err := tls.X509KeyPair([]byte(config.TLSClientCert), []byte(config.TLSClientKey)); err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		encrypted, err := encryptSecret(config.TLSClientKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt tls_client_key: %w", err)
+		}
+		config.TLSKeyEncrypted = encrypted
+	case previous != nil && previous.TLSKeyEncrypted != "":
+		// The certificate may have been replaced; the stored key has to match it
+		key, err := decryptSecret(previous.TLSKeyEncrypted)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt stored tls_client_key: %w", err)
+		}
+		if _, err := tls.X509KeyPair([]byte(config.TLSClientCert), []byte(key)); err != nil {
+			return fmt.Errorf("tls_client_key is required, the stored key does not match the certificate: %w", err)
+		}
+		config.TLSKeyEncrypted = previous.TLSKeyEncrypted
+	default:
+		return errors.New("tls_client_key is required")
+	}
+	config.TLSClientKey = ""
+	return nil
+}
+
+func validateAssertion(assertion *database.CheckAssertion) error {
+	switch assertion.Type {
+	case "contains", "not_contains":
+		if assertion.Value == "" {
+			return fmt.Errorf("%s assertion requires a value", assertion.Type)
+		}
+	case "regex":
+		if _, err := regexp.Compile(assertion.Value); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case "json_path":
+		path := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(assertion.Path), "$"), ".")
+		if path == "" {
+			return errors.New("json_path assertion requires a path")
+		}
+		if assertion.Operator == "" {
+			assertion.Operator = "eq"
+		}
+		switch assertion.Operator {
+		case "eq", "ne":
+		case "gt", "gte", "lt", "lte":
+			if _, err := strconv.ParseFloat(assertion.Value, 64); err != nil {
+				return fmt.Errorf("operator %s requires a numeric value", assertion.Operator)
+			}
+		default:
+			return fmt.Errorf("unknown operator %q", assertion.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown assertion type %q", assertion.Type)
+	}
+	return nil
+}
+
+// validateStatusCodes accepts a comma separated list of codes and inclusive
+// ranges such as "200-299,301".
+func validateStatusCodes(spec string) error {
+	count := 0
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return fmt.Errorf("invalid status code %q", part)
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return fmt.Errorf("invalid status code range %q", part)
+			}
+		}
+		if min < 100 || max > 599 || min > max {
+			return fmt.Errorf("invalid status code range %q", part)
+		}
+		count++
+	}
+
+	if count == 0 {
+		return errors.New("expected_status_codes must contain at least one status code")
+	}
+	return nil
+}
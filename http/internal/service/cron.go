@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron expression: minute, hour, day of
+// month, month and day of week. Fields accept numbers, "*", ranges, steps
+// and comma separated lists; names such as MON or JAN are not supported.
+//
+// The HTTP API validates schedules with the same parser the ping-service
+// enforces them with: keep this file and cron_test.go identical to
+// ping-service/internal/cron.go apart from the package name.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is Sunday as well
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loPart); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			switch {
+			case isRange:
+				if hi, err = strconv.Atoi(hiPart); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+			case !hasStep:
+				hi = lo
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matches reports whether the schedule fires in the minute of t, in t's
+// location.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 ||
+		s.hour&(1<<uint(t.Hour())) == 0 ||
+		s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	return s.dayMatches(t)
+}
+
+// dayMatches reports whether the schedule fires on t's day. As in cron, a
+// restricted day of month and day of week match when either does.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// cronSearchYears bounds the search for the next match, so schedules that
+// never fire, such as "0 0 30 2 *", end.
+const cronSearchYears = 5
+
+// next returns the first minute at or after t in which the schedule fires,
+// in t's location, or the zero time if it does not fire within
+// cronSearchYears. It skips whole months, days and hours that cannot
+// match instead of testing every minute.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	location := t.Location()
+	if truncated := t.Truncate(time.Minute); truncated.Before(t) {
+		t = truncated.Add(time.Minute)
+	} else {
+		t = truncated
+	}
+
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location))
+		case !s.dayMatches(t):
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// Counting minutes keeps both runs of an hour repeated by DST
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cronAdvance returns candidate, or the minute after t when time.Date
+// normalised a wall clock time inside a DST change to an instant that is
+// not later than t.
+func cronAdvance(t, candidate time.Time) time.Time {
+	if candidate.After(t) {
+		return candidate
+	}
+	return t.Add(time.Minute)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "* * * * *"},
+		{expr: "0 2 * * 0"},
+		{expr: "*/15 9-17 * * 1-5"},
+		{expr: "0,30 0 1,15 * *"},
+		{expr: "5-50/5 * * 1-12/3 *"},
+		{expr: "0 0 * * 7"},
+		{expr: "  0   0 * * *  "},
+		{expr: "", wantErr: true},
+		{expr: "* * * *", wantErr: true},
+		{expr: "* * * * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * 32 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "10-5 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "*/x * * * *", wantErr: true},
+		{expr: "1-x * * * *", wantErr: true},
+		{expr: "MON * * * *", wantErr: true},
+		{expr: "* * * JAN *", wantErr: true},
+		{expr: "1,,2 * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := parseCron(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCron(%q) error = %v, want error %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   string
+		want bool
+	}{
+		{"* * * * *", "2026-03-04T05:06:00Z", true},
+		{"0 2 * * 0", "2026-03-08T02:00:00Z", true},
+		{"0 2 * * 0", "2026-03-08T02:01:00Z", false},
+		{"0 2 * * 0", "2026-03-09T02:00:00Z", false},
+		{"0 2 * * 7", "2026-03-08T02:00:00Z", true},
+		{"*/15 9-17 * * 1-5", "2026-03-09T17:45:00Z", true},
+		{"*/15 9-17 * * 1-5", "2026-03-09T18:00:00Z", false},
+		{"*/15 9-17 * * 1-5", "2026-03-09T09:10:00Z", false},
+		{"5-50/5 * * * *", "2026-03-09T00:50:00Z", true},
+		{"5-50/5 * * * *", "2026-03-09T00:55:00Z", false},
+		{"10/20 * * * *", "2026-03-09T00:50:00Z", true},
+		{"10/20 * * * *", "2026-03-09T00:00:00Z", false},
+		{"0 0 1,15 * *", "2026-03-15T00:00:00Z", true},
+		{"0 0 1,15 * *", "2026-03-16T00:00:00Z", false},
+		{"0 0 * 2 *", "2026-03-01T00:00:00Z", false},
+		// A restricted day of month and day of week match when either does
+		{"0 0 13 * 5", "2026-03-13T00:00:00Z", true},
+		{"0 0 13 * 5", "2026-03-20T00:00:00Z", true},
+		{"0 0 13 * 5", "2026-03-19T00:00:00Z", false},
+		{"0 0 13 * *", "2026-03-20T00:00:00Z", false},
+		{"0 0 * * 5", "2026-03-13T00:00:00Z", true},
+	}
+	for _, tt := range tests {
+		schedule, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.expr, err)
+		}
+		if got := schedule.matches(mustParseTime(t, tt.at)); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.expr, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name     string
+		expr     string
+		location *time.Location
+		from     string
+		want     string
+	}{
+		{"same minute", "30 2 * * *", time.UTC, "2026-03-04T02:30:00Z", "2026-03-04T02:30:00Z"},
+		{"rounds up to the minute", "* * * * *", time.UTC, "2026-03-04T02:30:01Z", "2026-03-04T02:31:00Z"},
+		{"later today", "30 2 * * *", time.UTC, "2026-03-04T01:00:00Z", "2026-03-04T02:30:00Z"},
+		{"tomorrow", "30 2 * * *", time.UTC, "2026-03-04T02:31:00Z", "2026-03-05T02:30:00Z"},
+		{"next weekday", "0 9 * * 1-5", time.UTC, "2026-03-06T10:00:00Z", "2026-03-09T09:00:00Z"},
+		{"next month", "0 0 1 * *", time.UTC, "2026-12-15T00:00:00Z", "2027-01-01T00:00:00Z"},
+		{"leap day", "0 0 29 2 *", time.UTC, "2026-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"never", "0 0 30 2 *", time.UTC, "2026-01-01T00:00:00Z", ""},
+		{"local time", "0 2 * * *", newYork, "2026-01-10T00:00:00Z", "2026-01-10T07:00:00Z"},
+		// 02:30 does not exist on the day clocks go forward
+		{"spring forward gap", "30 2 * * *", newYork, "2026-03-08T05:00:00Z", "2026-03-09T06:30:00Z"},
+		{"spring forward after gap", "30 3 * * *", newYork, "2026-03-08T05:00:00Z", "2026-03-08T07:30:00Z"},
+		// 01:30 happens twice on the day clocks go back
+		{"fall back first", "30 1 * * *", newYork, "2026-11-01T04:00:00Z", "2026-11-01T05:30:00Z"},
+		{"fall back second", "30 1 * * *", newYork, "2026-11-01T05:31:00Z", "2026-11-01T06:30:00Z"},
+		{"fall back next day", "30 1 * * *", newYork, "2026-11-01T06:31:00Z", "2026-11-02T06:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.expr, err)
+			}
+			got := schedule.next(mustParseTime(t, tt.from).In(tt.location))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Fatalf("next = %v, want none", got)
+				}
+				return
+			}
+			if want := mustParseTime(t, tt.want); !got.Equal(want) {
+				t.Fatalf("next = %v, want %v", got.UTC(), want)
+			}
+		})
+	}
+}
+
+// TestCronNextMatchesScan compares next with testing every minute, across
+// both DST changes of a year.
+func TestCronNextMatchesScan(t *testing.T) {
+	exprs := []string{
+		"* * * * *",
+		"30 2 * * *",
+		"0,30 1-3 * * *",
+		"*/7 */5 * * *",
+		"0 0 1,15 * 1",
+		"45 23 * * 0",
+		"0 12 31 * *",
+	}
+	locations := []*time.Location{
+		time.UTC,
+		mustLoadLocation(t, "America/New_York"),
+		mustLoadLocation(t, "Europe/Berlin"),
+		mustLoadLocation(t, "Asia/Kolkata"),
+		mustLoadLocation(t, "Australia/Lord_Howe"),
+	}
+	from := mustParseTime(t, "2026-01-01T00:00:00Z")
+	to := mustParseTime(t, "2027-01-01T00:00:00Z")
+
+	for _, expr := range exprs {
+		schedule, err := parseCron(expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", expr, err)
+		}
+		for _, location := range locations {
+			next := schedule.next(from.In(location))
+			for minute := from; minute.Before(to); minute = minute.Add(time.Minute) {
+				if !schedule.matches(minute.In(location)) {
+					continue
+				}
+				if !next.Equal(minute) {
+					t.Fatalf("%q in %s: next = %v, want %v", expr, location, next.UTC(), minute)
+				}
+				next = schedule.next(minute.Add(time.Minute).In(location))
+			}
+		}
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return location
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"http/internal/database"
+
+	"gorm.io/gorm"
+)
+
+const MaxProductDependencies = 20
+
+// ProductDependencies lists the products a product depends on and the
+// products that depend on it directly.
+type ProductDependencies struct {
+	DependsOn  []database.Product `json:"depends_on"`
+	Dependents []database.Product `json:"dependents"`
+}
+
+type DependencyService struct {
+	db *gorm.DB
+}
+
+func NewDependencyService(db *gorm.DB) (*DependencyService, error) {
+	if db == nil {
+		return nil, errors.New("database connection cannot be nil")
+	}
+	return &DependencyService{
+		db: db,
+	}, nil
+}
+
+func (s *DependencyService) GetDependencies(productID uint) (*ProductDependencies, error) {
+	if _, err := s.getProduct(productID); err != nil {
+		return nil, err
+	}
+
+	dependencies := ProductDependencies{
+		DependsOn:  []database.Product{},
+		Dependents: []database.Product{},
+	}
+	if err := s.db.Where("id IN (SELECT depends_on_id FROM product_dependencies WHERE product_id = ?)", productID).
+		Order("name").Find(&dependencies.DependsOn).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("id IN (SELECT product_id FROM product_dependencies WHERE depends_on_id = ?)", productID).
+		Order("name").Find(&dependencies.Dependents).Error; err != nil {
+		return nil, err
+	}
+	return &dependencies, nil
+}
+
+// SetDependencies replaces the products productID depends on. They must
+// belong to the same user and must not depend on productID themselves,
+// directly or transitively.
+func (s *DependencyService) SetDependencies(productID uint, dependsOn []uint) (*ProductDependencies, error) {
+	product, err := s.getProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(dependsOn))
+	seen := make(map[uint]bool, len(dependsOn))
+	for _, id := range dependsOn {
+		if id == productID {
+			return nil, errors.New("a product cannot depend on itself")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxProductDependencies {
+		return nil, fmt.Errorf("a product can have at most %d dependencies", MaxProductDependencies)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if len(ids) > 0 {
+			var count int64
+			if err := tx.Model(&database.Product{}).Where("id IN ? AND user_id = ?", ids, product.UserID).Count(&count).Error; err != nil {
+				return err
+			}
+			if int(count) != len(ids) {
+				return errors.New("dependency product not found")
+			}
+			if err := checkDependencyCycle(tx, productID, ids); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&database.ProductDependency{}).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := tx.Create(&database.ProductDependency{ProductID: productID, DependsOnID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDependencies(productID)
+}
+
+func (s *DependencyService) getProduct(productID uint) (*database.Product, error) {
+	var product database.Product
+	if err := s.db.First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// checkDependencyCycle rejects dependencies on products that already depend
+// on productID through their own upstream products.
+func checkDependencyCycle(tx *gorm.DB, productID uint, dependsOn []uint) error {
+	var name string
+	err := tx.Raw(`WITH RECURSIVE upstream(root_id, product_id) AS (
+		SELECT id, id FROM products WHERE id IN ?
+		UNION
+		SELECT upstream.root_id, d.depends_on_id FROM product_dependencies d JOIN upstream ON d.product_id = upstream.product_id
+	)
+	SELECT p.name FROM upstream JOIN products p ON p.id = upstream.root_id
+	WHERE upstream.product_id = ? ORDER BY p.name LIMIT 1`, dependsOn, productID).Scan(&name).Error
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		return fmt.Errorf("dependency on %q would create a cycle", name)
+	}
+	return nil
+}
@@ -40,7 +40,7 @@ func (s *DowntimeService) GetDowntime(productID uint, startDate, endDate, status
 	}
 
 	// Order by start time descending (most recent first)
-	if err := query.Order("start_time DESC").Find(&downtime).Error; err != nil {
+	if err := query.Preload("LocationResults").Order("start_time DESC").Find(&downtime).Error; err != nil {
 		return nil, err
 	}
 
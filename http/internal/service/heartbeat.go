@@ -0,0 +1,104 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"http/internal/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CheckTypeHeartbeat = "heartbeat"
+
+	// HeartbeatTokenPrefix makes heartbeat tokens recognisable in job configs.
+	HeartbeatTokenPrefix = "obh_"
+)
+
+type HeartbeatService struct {
+	db *gorm.DB
+}
+
+func NewHeartbeatService(db *gorm.DB) (*HeartbeatService, error) {
+	if db == nil {
+		return nil, errors.New("database connection cannot be nil")
+	}
+	return &HeartbeatService{
+		db: db,
+	}, nil
+}
+
+// RecordHeartbeat stores a heartbeat for the product the token belongs to
+// and lets the ping-service know, so an open downtime closes right away.
+func (s *HeartbeatService) RecordHeartbeat(token string) error {
+	var config database.CheckConfig
+	if err := s.db.Select("product_id").
+		Where("heartbeat_token = ? AND check_type = ?", token, CheckTypeHeartbeat).
+		First(&config).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("heartbeat not found")
+		}
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		heartbeat := database.Heartbeat{
+			ProductID:  config.ProductID,
+			ReceivedAt: time.Now(),
+		}
+		if err := tx.Omit("Product").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"received_at"}),
+		}).Create(&heartbeat).Error; err != nil {
+			return err
+		}
+		return notifyProductChange(tx, config.ProductID, ProductChangeHeartbeat)
+	})
+}
+
+func newHeartbeatToken() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return HeartbeatTokenPrefix + hex.EncodeToString(secret), nil
+}
+
+// assignHeartbeatToken gives heartbeat checks their token. The token, and
+// with it the heartbeat URL, stays the same across updates so jobs need not
+// be reconfigured.
+func assignHeartbeatToken(config *database.CheckConfig) error {
+	if config.CheckType != CheckTypeHeartbeat {
+		config.HeartbeatToken = nil
+		return nil
+	}
+	if config.HeartbeatToken == nil {
+		token, err := newHeartbeatToken()
+		if err != nil {
+			return err
+		}
+		config.HeartbeatToken = &token
+	}
+	return nil
+}
+
+// validateHeartbeatAgent rejects running a heartbeat check on an agent; the
+// heartbeats are received centrally.
+func validateHeartbeatAgent(db *gorm.DB, productID uint, agentID *uint) error {
+	if agentID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&database.CheckConfig{}).
+		Where("product_id = ? AND check_type = ?", productID, CheckTypeHeartbeat).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("heartbeat checks cannot be assigned to an agent")
+	}
+	return nil
+}
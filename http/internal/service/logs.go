@@ -2,6 +2,7 @@ package service
 
 import (
 	"http/internal/database"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,7 +18,7 @@ func NewLogsService(db *gorm.DB) (*LogsService, error) {
 	}, nil
 }
 
-func (s *LogsService) GetLogs(productID uint, limit, page int, level, startDate, endDate string) ([]database.Log, int, error) {
+func (s *LogsService) GetLogs(productID uint, limit, page int, level, traceID, startDate, endDate string) ([]database.Log, int, error) {
 	var logs []database.Log
 	var total int64
 
@@ -25,8 +26,14 @@ func (s *LogsService) GetLogs(productID uint, limit, page int, level, startDate,
 
 	// Apply filters
 	if level != "" {
-		// Filter by log level in JSON data
-		query = query.Where("log_data LIKE ?", "%\"level\":\""+level+"\"%")
+		// Entries of v1 clients have no level, so fall back to the level in
+		// their JSON data
+		query = query.Where("level = ? OR ((level IS NULL OR level = '') AND log_data LIKE ?)",
+			strings.ToLower(level), "%\"level\":\""+level+"\"%")
+	}
+
+	if traceID != "" {
+		query = query.Where("trace_id = ?", strings.ToLower(traceID))
 	}
 
 	if startDate != "" {
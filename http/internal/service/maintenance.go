@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"http/internal/database"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const MaxMaintenanceDurationMinutes = 7 * 24 * 60
+
+type MaintenanceService struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceService(db *gorm.DB) (*MaintenanceService, error) {
+	if db == nil {
+		return nil, errors.New("database connection cannot be nil")
+	}
+	return &MaintenanceService{
+		db: db,
+	}, nil
+}
+
+func (s *MaintenanceService) GetMaintenanceWindows(productID uint) ([]database.MaintenanceWindow, error) {
+	if err := s.ensureProduct(productID); err != nil {
+		return nil, err
+	}
+
+	var windows []database.MaintenanceWindow
+	if err := s.db.Where("product_id = ?", productID).Order("created_at").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (s *MaintenanceService) CreateMaintenanceWindow(productID uint, window database.MaintenanceWindow) (*database.MaintenanceWindow, error) {
+	if err := s.ensureProduct(productID); err != nil {
+		return nil, err
+	}
+	if err := ValidateMaintenanceWindow(&window); err != nil {
+		return nil, err
+	}
+
+	window.ID = 0
+	window.ProductID = productID
+	if err := s.db.Create(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (s *MaintenanceService) UpdateMaintenanceWindow(productID, windowID uint, updated database.MaintenanceWindow) (*database.MaintenanceWindow, error) {
+	var window database.MaintenanceWindow
+	if err := s.db.Where("id = ? AND product_id = ?", windowID, productID).First(&window).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("maintenance window not found")
+		}
+		return nil, err
+	}
+	if err := ValidateMaintenanceWindow(&updated); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":             updated.Name,
+		"start_time":       updated.StartTime,
+		"end_time":         updated.EndTime,
+		"schedule":         updated.Schedule,
+		"duration_minutes": updated.DurationMinutes,
+		"timezone":         updated.Timezone,
+		"updated_at":       time.Now(),
+	}
+	if err := s.db.Model(&window).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&window, window.ID).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (s *MaintenanceService) DeleteMaintenanceWindow(productID, windowID uint) error {
+	result := s.db.Where("id = ? AND product_id = ?", windowID, productID).Delete(&database.MaintenanceWindow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("maintenance window not found")
+	}
+	return nil
+}
+
+func (s *MaintenanceService) ensureProduct(productID uint) error {
+	var count int64
+	if err := s.db.Model(&database.Product{}).Where("id = ?", productID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+// ValidateMaintenanceWindow checks that a window is either one-off (start
+// and end time) or recurring (schedule and duration), and normalises the
+// fields of the other kind away.
+func ValidateMaintenanceWindow(window *database.MaintenanceWindow) error {
+	window.Schedule = strings.TrimSpace(window.Schedule)
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", window.Timezone)
+	}
+
+	if window.Schedule == "" {
+		if window.StartTime == nil || window.EndTime == nil {
+			return errors.New("start_time and end_time are required unless a schedule is given")
+		}
+		if !window.EndTime.After(*window.StartTime) {
+			return errors.New("end_time must be after start_time")
+		}
+		window.DurationMinutes = 0
+		return nil
+	}
+
+	if window.StartTime != nil || window.EndTime != nil {
+		return errors.New("start_time and end_time cannot be combined with a schedule")
+	}
+	if _, err := parseCron(window.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if window.DurationMinutes < 1 || window.DurationMinutes > MaxMaintenanceDurationMinutes {
+		return fmt.Errorf("duration_minutes must be between 1 and %d", MaxMaintenanceDurationMinutes)
+	}
+	return nil
+}
+
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// maintenanceRanges returns the merged time ranges within [from, to] that are
+// covered by the given windows. Invalid windows are skipped.
+func maintenanceRanges(windows []database.MaintenanceWindow, from, to time.Time) []timeRange {
+	var ranges []timeRange
+	add := func(start, end time.Time) {
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			return
+		}
+		// Starts of one window come in order, so most ranges extend the last
+		if n := len(ranges); n > 0 && !start.Before(ranges[n-1].start) && !start.After(ranges[n-1].end) {
+			if end.After(ranges[n-1].end) {
+				ranges[n-1].end = end
+			}
+			return
+		}
+		ranges = append(ranges, timeRange{start: start, end: end})
+	}
+
+	for _, window := range windows {
+		if window.Schedule == "" {
+			if window.StartTime != nil && window.EndTime != nil {
+				add(*window.StartTime, *window.EndTime)
+			}
+			continue
+		}
+
+		schedule, err := parseCron(window.Schedule)
+		if err != nil {
+			continue
+		}
+		location, err := time.LoadLocation(window.Timezone)
+		if err != nil {
+			continue
+		}
+		duration := time.Duration(window.DurationMinutes) * time.Minute
+		for start := schedule.next(from.Add(-duration).In(location)); !start.IsZero() && start.Before(to); start = schedule.next(start.Add(time.Minute)) {
+			add(start, start.Add(duration))
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Before(ranges[j].start) })
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && !r.start.After(merged[n-1].end) {
+			if r.end.After(merged[n-1].end) {
+				merged[n-1].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// overlap is how much of [start, end] falls into the merged ranges.
+func overlap(start, end time.Time, ranges []timeRange) time.Duration {
+	var total time.Duration
+	for _, r := range ranges {
+		s, e := r.start, r.end
+		if s.Before(start) {
+			s = start
+		}
+		if e.After(end) {
+			e = end
+		}
+		if e.After(s) {
+			total += e.Sub(s)
+		}
+	}
+	return total
+}
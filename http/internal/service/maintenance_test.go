@@ -0,0 +1,86 @@
+package service
+
+import (
+	"http/internal/database"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceRanges(t *testing.T) {
+	from := mustParseTime(t, "2026-03-02T00:00:00Z")
+	to := mustParseTime(t, "2026-03-04T00:00:00Z")
+	start := mustParseTime(t, "2026-03-01T22:00:00Z")
+	end := mustParseTime(t, "2026-03-02T01:00:00Z")
+
+	tests := []struct {
+		name    string
+		windows []database.MaintenanceWindow
+		want    []string
+	}{
+		{"none", nil, nil},
+		{"one-off clamped to the period", []database.MaintenanceWindow{{StartTime: &start, EndTime: &end}},
+			[]string{"2026-03-02T00:00:00Z", "2026-03-02T01:00:00Z"}},
+		{"daily", []database.MaintenanceWindow{{Schedule: "0 2 * * *", DurationMinutes: 30, Timezone: "UTC"}},
+			[]string{"2026-03-02T02:00:00Z", "2026-03-02T02:30:00Z", "2026-03-03T02:00:00Z", "2026-03-03T02:30:00Z"}},
+		{"started before the period", []database.MaintenanceWindow{{Schedule: "0 23 * * *", DurationMinutes: 120, Timezone: "UTC"}},
+			[]string{"2026-03-02T00:00:00Z", "2026-03-02T01:00:00Z", "2026-03-02T23:00:00Z", "2026-03-03T01:00:00Z", "2026-03-03T23:00:00Z", "2026-03-04T00:00:00Z"}},
+		{"overlapping starts merge", []database.MaintenanceWindow{{Schedule: "*/10 3 * * *", DurationMinutes: 15, Timezone: "UTC"}},
+			[]string{"2026-03-02T03:00:00Z", "2026-03-02T04:05:00Z", "2026-03-03T03:00:00Z", "2026-03-03T04:05:00Z"}},
+		{"windows merge", []database.MaintenanceWindow{
+			{Schedule: "0 2 * * *", DurationMinutes: 60, Timezone: "UTC"},
+			{Schedule: "30 2 * * 1", DurationMinutes: 60, Timezone: "UTC"},
+		}, []string{"2026-03-02T02:00:00Z", "2026-03-02T03:30:00Z", "2026-03-03T02:00:00Z", "2026-03-03T03:00:00Z"}},
+		{"timezone", []database.MaintenanceWindow{{Schedule: "0 2 * * 2", DurationMinutes: 60, Timezone: "Asia/Kolkata"}},
+			[]string{"2026-03-02T20:30:00Z", "2026-03-02T21:30:00Z"}},
+		{"invalid windows are skipped", []database.MaintenanceWindow{
+			{Schedule: "0 25 * * *", DurationMinutes: 60, Timezone: "UTC"},
+			{Schedule: "0 2 * * *", DurationMinutes: 60, Timezone: "Mars/Olympus"},
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range maintenanceRanges(tt.windows, from, to) {
+				got = append(got, r.start.UTC().Format(time.RFC3339), r.end.UTC().Format(time.RFC3339))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ranges = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ranges = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMaintenanceWindow(t *testing.T) {
+	start := mustParseTime(t, "2026-03-02T00:00:00Z")
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		window  database.MaintenanceWindow
+		wantErr bool
+	}{
+		{"one-off", database.MaintenanceWindow{StartTime: &start, EndTime: &end}, false},
+		{"one-off reversed", database.MaintenanceWindow{StartTime: &end, EndTime: &start}, true},
+		{"one-off without end", database.MaintenanceWindow{StartTime: &start}, true},
+		{"recurring", database.MaintenanceWindow{Schedule: "0 2 * * 0", DurationMinutes: 60}, false},
+		{"recurring with times", database.MaintenanceWindow{Schedule: "0 2 * * 0", DurationMinutes: 60, StartTime: &start}, true},
+		{"invalid schedule", database.MaintenanceWindow{Schedule: "0 2 * *", DurationMinutes: 60}, true},
+		{"zero duration", database.MaintenanceWindow{Schedule: "0 2 * * 0"}, true},
+		{"longest duration", database.MaintenanceWindow{Schedule: "0 2 * * 0", DurationMinutes: MaxMaintenanceDurationMinutes}, false},
+		{"too long", database.MaintenanceWindow{Schedule: "0 2 * * 0", DurationMinutes: MaxMaintenanceDurationMinutes + 1}, true},
+		{"invalid timezone", database.MaintenanceWindow{Schedule: "0 2 * * 0", DurationMinutes: 60, Timezone: "Mars/Olympus"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMaintenanceWindow(&tt.window)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateMaintenanceWindow error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// ProductChangesChannel is the Postgres NOTIFY channel the ping-service
+// listens on to pick up product and check config changes.
+const ProductChangesChannel = "product_changes"
+
+const (
+	ProductChangeUpsert = "upsert"
+	ProductChangeDelete = "delete"
+
+	// ProductChangeHeartbeat asks the ping-service to check a heartbeat
+	// product immediately.
+	ProductChangeHeartbeat = "heartbeat"
+)
+
+type productChange struct {
+	ProductID uint   `json:"product_id"`
+	Action    string `json:"action"`
+}
+
+// notifyProductChange queues a notification on tx; Postgres delivers it
+// only when the transaction commits.
+func notifyProductChange(tx *gorm.DB, productID uint, action string) error {
+	payload, err := json.Marshal(productChange{ProductID: productID, Action: action})
+	if err != nil {
+		return err
+	}
+	return tx.Exec("SELECT pg_notify(?, ?)", ProductChangesChannel, string(payload)).Error
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"http/internal/database"
 	"strconv"
@@ -9,6 +10,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// ProductUpdate is the body of a product update. HealthAPI and AgentID are
+// only changed when the request sends them; "agent_id": null unassigns the
+// product from its agent.
+type ProductUpdate struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	HealthAPI   *string    `json:"health_api"`
+	AgentID     OptionalID `json:"agent_id"`
+}
+
+// OptionalID is an ID field that tells an absent field apart from null.
+type OptionalID struct {
+	Set   bool
+	Value *uint
+}
+
+func (o *OptionalID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
 type ProductService struct {
 	db *gorm.DB
 }
@@ -31,6 +53,23 @@ func (s *ProductService) CreateProduct(product database.Product) (*database.Prod
 	if product.UserID == 0 {
 		return nil, errors.New("user ID is required")
 	}
+	if product.CheckConfig != nil {
+		ApplyCheckConfigDefaults(product.CheckConfig)
+		if err := ValidateCheckConfig(product.CheckConfig); err != nil {
+			return nil, err
+		}
+		if err := sealCredentials(product.CheckConfig, nil); err != nil {
+			return nil, err
+		}
+		// A client-supplied token would let it choose the heartbeat URL
+		product.CheckConfig.HeartbeatToken = nil
+		if err := assignHeartbeatToken(product.CheckConfig); err != nil {
+			return nil, err
+		}
+		if product.AgentID != nil && product.CheckConfig.CheckType == CheckTypeHeartbeat {
+			return nil, errors.New("heartbeat checks cannot be assigned to an agent")
+		}
+	}
 
 	var user database.User
 	if err := s.db.First(&user, product.UserID).Error; err != nil {
@@ -39,8 +78,17 @@ func (s *ProductService) CreateProduct(product database.Product) (*database.Prod
 		}
 		return nil, err
 	}
+	if err := validateProductAgent(s.db, product.UserID, product.AgentID); err != nil {
+		return nil, err
+	}
 
-	if err := s.db.Create(&product).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		return notifyProductChange(tx, product.ID, ProductChangeUpsert)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -54,7 +102,7 @@ func (s *ProductService) GetProduct(productID string) (*database.Product, error)
 	}
 
 	var product database.Product
-	if err := s.db.Preload("User").First(&product, uint(id)).Error; err != nil {
+	if err := s.db.Preload("User").Preload("CheckConfig.Assertions").Preload("CheckConfig.Headers").Preload("CheckConfig.Steps", orderedSteps).First(&product, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("product not found")
 		}
@@ -80,7 +128,7 @@ func (s *ProductService) GetProductsByUser(userID uint) ([]database.Product, err
 	return products, nil
 }
 
-func (s *ProductService) UpdateProduct(productID string, updatedProduct database.Product) (*database.Product, error) {
+func (s *ProductService) UpdateProduct(productID string, updatedProduct ProductUpdate) (*database.Product, error) {
 	id, err := strconv.ParseUint(productID, 10, 32)
 	if err != nil {
 		return nil, errors.New("invalid product ID")
@@ -103,8 +151,26 @@ func (s *ProductService) UpdateProduct(productID string, updatedProduct database
 		"description": updatedProduct.Description,
 		"updated_at":  time.Now(),
 	}
+	if updatedProduct.HealthAPI != nil {
+		updates["health_api"] = *updatedProduct.HealthAPI
+	}
+	if updatedProduct.AgentID.Set {
+		if err := validateProductAgent(s.db, product.UserID, updatedProduct.AgentID.Value); err != nil {
+			return nil, err
+		}
+		if err := validateHeartbeatAgent(s.db, product.ID, updatedProduct.AgentID.Value); err != nil {
+			return nil, err
+		}
+		updates["agent_id"] = updatedProduct.AgentID.Value
+	}
 
-	if err := s.db.Model(&product).Updates(updates).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		return notifyProductChange(tx, product.ID, ProductChangeUpsert)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -129,18 +195,30 @@ func (s *ProductService) DeleteProduct(productID string) error {
 		return err
 	}
 
-	if err := s.db.Delete(&product).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return notifyProductChange(tx, product.ID, ProductChangeDelete)
+	})
 }
 
 func (s *ProductService) DeleteProductsByUser(userID uint) error {
-	if err := s.db.Where("user_id = ?", userID).Delete(&database.Product{}).Error; err != nil {
-		return err
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var productIDs []uint
+		if err := tx.Model(&database.Product{}).Where("user_id = ?", userID).Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&database.Product{}).Error; err != nil {
+			return err
+		}
+		for _, productID := range productIDs {
+			if err := notifyProductChange(tx, productID, ProductChangeDelete); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *ProductService) SearchProducts(query string) ([]database.Product, error) {
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CheckSecretsKeyEnv holds the base64 encoded AES-256 key check credentials
+// are encrypted with. The HTTP service and the ping-service share the key.
+const CheckSecretsKeyEnv = "CHECK_SECRETS_KEY"
+
+func secretsCipher() (cipher.AEAD, error) {
+	encoded := os.Getenv(CheckSecretsKeyEnv)
+	if encoded == "" {
+		return nil, errors.New(CheckSecretsKeyEnv + " is not configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must be a base64 encoded 32 byte key", CheckSecretsKeyEnv)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret seals plaintext with AES-GCM; the random nonce is stored in
+// front of the ciphertext. The ping-service decrypts with its own copy of
+// decryptSecret in ping-service/internal/secrets.go, so a format change must
+// be made in both.
+func encryptSecret(plaintext string) (string, error) {
+	aead, err := secretsCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(encoded string) (string, error) {
+	aead, err := secretsCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import "testing"
+
+// testSecretsKey and testSealedSecret are shared with secrets_test.go of the
+// other module: a secret sealed by the HTTP service must open in the
+// ping-service.
+const (
+	testSecretsKey   = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testSealedSecret = "zN1DW3bWlYN5RmXldDDiAsCfK95ztwkn7cMXl+F7mzLq+NI="
+)
+
+func TestDecryptSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		sealed  string
+		want    string
+		wantErr bool
+	}{
+		{name: "sealed by the http service", key: testSecretsKey, sealed: testSealedSecret, want: "hunter2"},
+		{name: "no key", sealed: testSealedSecret, wantErr: true},
+		{name: "key too short", key: "c2hvcnQ=", sealed: testSealedSecret, wantErr: true},
+		{name: "other key", key: "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=", sealed: testSealedSecret, wantErr: true},
+		{name: "not base64", key: testSecretsKey, sealed: "not base64!", wantErr: true},
+		{name: "shorter than the nonce", key: testSecretsKey, sealed: "c2hvcnQ=", wantErr: true},
+		{name: "tampered", key: testSecretsKey, sealed: "zN1DW3bWlYN5RmXldDDiAsCfK95ztwkn7cMXl+F7mzLq+NA=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(CheckSecretsKeyEnv, tt.key)
+			got, err := decryptSecret(tt.sealed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decryptSecret error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("decryptSecret = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	t.Setenv(CheckSecretsKeyEnv, testSecretsKey)
+	first, err := encryptSecret("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := encryptSecret("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("sealing the same secret twice must use a fresh nonce")
+	}
+	if plaintext, err := decryptSecret(first); err != nil || plaintext != "hunter2" {
+		t.Fatalf("decryptSecret = %q, %v", plaintext, err)
+	}
+}
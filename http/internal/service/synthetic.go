@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"http/internal/database"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CheckTypeSynthetic = "synthetic"
+
+	DefaultStepTimeoutMs = 10000
+	MaxCheckSteps        = 20
+)
+
+var (
+	stepVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	stepVariableRef  = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// orderedSteps preloads the steps of a synthetic check in request order.
+func orderedSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// validateCheckSteps checks the steps of a synthetic check and numbers them
+// in request order. A step may only use variables extracted by the steps
+// before it.
+func validateCheckSteps(config *database.CheckConfig) error {
+	if config.CheckType != CheckTypeSynthetic {
+		if len(config.Steps) > 0 {
+			return errors.New("steps are only supported for synthetic checks")
+		}
+		return nil
+	}
+	if len(config.Steps) == 0 {
+		return errors.New("synthetic checks require at least one step")
+	}
+	if len(config.Steps) > MaxCheckSteps {
+		return fmt.Errorf("at most %d steps are allowed", MaxCheckSteps)
+	}
+
+	defined := make(map[string]bool)
+	for i := range config.Steps {
+		step := &config.Steps[i]
+		step.Position = i + 1
+		if err := validateCheckStep(step, config.TimeoutMs, defined); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for name := range step.Extract {
+			defined[name] = true
+		}
+	}
+	return nil
+}
+
+func validateCheckStep(step *database.CheckStep, checkTimeoutMs int, defined map[string]bool) error {
+	step.Name = strings.TrimSpace(step.Name)
+	if step.Name == "" {
+		return errors.New("name is required")
+	}
+
+	step.Method = strings.ToUpper(strings.TrimSpace(step.Method))
+	if step.Method == "" {
+		step.Method = DefaultCheckMethod
+	}
+	switch step.Method {
+	case "GET", "HEAD":
+		if step.Body != "" {
+			return fmt.Errorf("body cannot be sent with %s", step.Method)
+		}
+	case "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+	default:
+		return fmt.Errorf("unsupported method %q", step.Method)
+	}
+
+	step.URL = strings.TrimSpace(step.URL)
+	if step.URL == "" {
+		return errors.New("url is required")
+	}
+	// Variables are substituted at check time, so only the scheme is known here
+	if u, err := url.Parse(stepVariableRef.ReplaceAllString(step.URL, "x")); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("url %q must be an http or https URL", step.URL)
+	}
+
+	if strings.TrimSpace(step.ExpectedStatusCodes) == "" {
+		step.ExpectedStatusCodes = DefaultExpectedStatus
+	}
+	if err := validateStatusCodes(step.ExpectedStatusCodes); err != nil {
+		return err
+	}
+	if step.TimeoutMs == 0 {
+		step.TimeoutMs = min(DefaultStepTimeoutMs, checkTimeoutMs)
+	}
+	if step.TimeoutMs < 100 || step.TimeoutMs > checkTimeoutMs {
+		return fmt.Errorf("timeout_ms must be between 100 and the check's timeout_ms (%d)", checkTimeoutMs)
+	}
+
+	if len(step.Headers) > MaxCheckHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxCheckHeaders)
+	}
+	for name, value := range step.Headers {
+		if !validHeaderName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("header %s contains a line break", name)
+		}
+	}
+
+	for name, path := range step.Extract {
+		if !stepVariableName.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+		if strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".") == "" {
+			return fmt.Errorf("extract %s requires a path", name)
+		}
+	}
+
+	for i := range step.Assertions {
+		a := &step.Assertions[i]
+		assertion := database.CheckAssertion{Type: a.Type, Path: a.Path, Operator: a.Operator, Value: a.Value}
+		if err := validateAssertion(&assertion); err != nil {
+			return fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+		a.Operator = assertion.Operator
+	}
+
+	uses := []string{step.URL, step.Body}
+	for _, value := range step.Headers {
+		uses = append(uses, value)
+	}
+	for _, use := range uses {
+		for _, match := range stepVariableRef.FindAllStringSubmatch(use, -1) {
+			if !defined[match[1]] {
+				return fmt.Errorf("variable %s is not extracted by an earlier step", match[1])
+			}
+		}
+	}
+	return nil
+}
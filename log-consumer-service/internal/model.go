@@ -32,12 +32,21 @@ type Product struct {
 	Logs        []Log     `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
+// Log is a log entry of a product. Entries sent through the v1 ingestion
+// API only have LogData and Timestamp.
 type Log struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID uint      `gorm:"not null;index" json:"product_id"`
-	Product   Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	LogData   string    `gorm:"type:text;not null" json:"log_data"`
-	Timestamp time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID         uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID  uint              `gorm:"not null;index" json:"product_id"`
+	Product    Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	LogData    string            `gorm:"type:text;not null" json:"log_data"`
+	Level      string            `gorm:"size:10;index" json:"level,omitempty"` // "trace", "debug", "info", "warn", "error", "fatal"
+	Attributes map[string]string `gorm:"serializer:json;type:jsonb" json:"attributes,omitempty"`
+	Logger     string            `gorm:"size:255" json:"logger,omitempty"`
+	Hostname   string            `gorm:"size:255" json:"hostname,omitempty"`
+	Instance   string            `gorm:"size:255" json:"instance,omitempty"`
+	TraceID    string            `gorm:"size:32;index" json:"trace_id,omitempty"`
+	SpanID     string            `gorm:"size:16" json:"span_id,omitempty"`
+	Timestamp  time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
 type Downtime struct {
@@ -152,11 +152,19 @@ func (app *Application) messageHandler(key, value string) {
 		return
 	}
 
+	// Batches published for v1 clients only carry the timestamp and message
 	var batch struct {
 		ProductID string `json:"product_id"`
 		Logs      []struct {
-			Timestamp string `json:"timestamp"`
-			Message   string `json:"message"`
+			Timestamp  string            `json:"timestamp"`
+			Message    string            `json:"message"`
+			Level      string            `json:"level"`
+			Attributes map[string]string `json:"attributes"`
+			Logger     string            `json:"logger"`
+			Hostname   string            `json:"hostname"`
+			Instance   string            `json:"instance"`
+			TraceID    string            `json:"trace_id"`
+			SpanID     string            `json:"span_id"`
 		} `json:"logs"`
 	}
 
@@ -175,9 +183,16 @@ func (app *Application) messageHandler(key, value string) {
 		}
 
 		logs = append(logs, internal.Log{
-			ProductID: uint(productID),
-			LogData:   logEntry.Message,
-			Timestamp: timestamp,
+			ProductID:  uint(productID),
+			LogData:    logEntry.Message,
+			Level:      logEntry.Level,
+			Attributes: logEntry.Attributes,
+			Logger:     logEntry.Logger,
+			Hostname:   logEntry.Hostname,
+			Instance:   logEntry.Instance,
+			TraceID:    logEntry.TraceID,
+			SpanID:     logEntry.SpanID,
+			Timestamp:  timestamp,
 		})
 	}
 
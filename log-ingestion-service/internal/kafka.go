@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	pb "log-ingestion-service/proto"
-
 	"github.com/segmentio/kafka-go"
 )
 
@@ -24,31 +22,28 @@ func NewProducer(brokers []string, topic string) *Producer {
 	}
 }
 
-func (p *Producer) Write(ctx context.Context, logs []*pb.LogEntry, key string) error {
+// LogRecord is a log entry as published to the logs topic. Entries of v1
+// clients only carry the timestamp and message.
+type LogRecord struct {
+	Timestamp  string            `json:"timestamp"`
+	Message    string            `json:"message"`
+	Level      string            `json:"level,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Logger     string            `json:"logger,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Instance   string            `json:"instance,omitempty"`
+	TraceID    string            `json:"trace_id,omitempty"`
+	SpanID     string            `json:"span_id,omitempty"`
+}
+
+func (p *Producer) Write(ctx context.Context, logs []LogRecord, key string) error {
 	// Create a batch structure
 	batch := struct {
-		ProductID string `json:"product_id"`
-		Logs      []struct {
-			Timestamp string `json:"timestamp"`
-			Message   string `json:"message"`
-		} `json:"logs"`
+		ProductID string      `json:"product_id"`
+		Logs      []LogRecord `json:"logs"`
 	}{
 		ProductID: key,
-		Logs: make([]struct {
-			Timestamp string `json:"timestamp"`
-			Message   string `json:"message"`
-		}, len(logs)),
-	}
-
-	// Convert protobuf logs to JSON structure
-	for i, log := range logs {
-		batch.Logs[i] = struct {
-			Timestamp string `json:"timestamp"`
-			Message   string `json:"message"`
-		}{
-			Timestamp: log.GetTimestamp(),
-			Message:   log.GetMessage(),
-		}
+		Logs:      logs,
 	}
 
 	// Serialize the entire batch as JSON
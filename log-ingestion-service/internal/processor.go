@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	pb "log-ingestion-service/proto"
+	pbv2 "log-ingestion-service/proto/v2"
 
 	r "github.com/rushikeshg25/token-bucket"
 )
@@ -45,25 +46,35 @@ func NewProcessor() (*Processor, error) {
 }
 
 func (p *Processor) ProcessLogs(ctx context.Context, req *pb.IngestEventRequest) error {
-	// productIDStr := req.ServiceId
+	return p.ingest(ctx, req.ServiceId, req.AuthToken, recordsFromV1(req.Logs))
+}
+
+func (p *Processor) ProcessLogsV2(ctx context.Context, req *pbv2.IngestEventRequest) error {
+	records, err := recordsFromV2(req.Logs)
+	if err != nil {
+		return err
+	}
+	return p.ingest(ctx, req.ServiceId, req.AuthToken, records)
+}
 
-	productIDStr, err := p.redis.Get(req.AuthToken, ctx)
+func (p *Processor) ingest(ctx context.Context, serviceID, authToken string, records []LogRecord) error {
+	productIDStr, err := p.redis.Get(authToken, ctx)
 	if err == nil {
-		if productIDStr != req.ServiceId {
-			return fmt.Errorf("invalid service id: %s", req.ServiceId)
+		if productIDStr != serviceID {
+			return fmt.Errorf("invalid service id: %s", serviceID)
 		}
 	} else {
 		var product Product
-		if err := p.db.DB.Where("auth_token = ?", req.AuthToken).First(&product).Error; err != nil {
+		if err := p.db.DB.Where("auth_token = ?", authToken).First(&product).Error; err != nil {
 			return fmt.Errorf("invalid auth token: %w", err)
 		}
 
 		productIDStr = fmt.Sprintf("%d", product.ID)
-		if productIDStr != req.ServiceId {
-			return fmt.Errorf("invalid service id: %s", req.ServiceId)
+		if productIDStr != serviceID {
+			return fmt.Errorf("invalid service id: %s", serviceID)
 		}
 
-		if err := p.redis.Set(req.AuthToken, productIDStr, ctx); err != nil {
+		if err := p.redis.Set(authToken, productIDStr, ctx); err != nil {
 			log.Printf("Warning: failed to cache auth token in Redis: %v", err)
 		}
 	}
@@ -78,7 +89,7 @@ func (p *Processor) ProcessLogs(ctx context.Context, req *pb.IngestEventRequest)
 
 	err = p.kafka.Write(
 		ctx,
-		req.Logs,
+		records,
 		productIDStr,
 	)
 	if err != nil {
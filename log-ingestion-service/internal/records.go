@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	pb "log-ingestion-service/proto"
+	pbv2 "log-ingestion-service/proto/v2"
+)
+
+// MaxLogAttributes caps the attributes of a single log entry.
+const MaxLogAttributes = 64
+
+var severityLevels = map[pbv2.Severity]string{
+	pbv2.Severity_SEVERITY_TRACE: "trace",
+	pbv2.Severity_SEVERITY_DEBUG: "debug",
+	pbv2.Severity_SEVERITY_INFO:  "info",
+	pbv2.Severity_SEVERITY_WARN:  "warn",
+	pbv2.Severity_SEVERITY_ERROR: "error",
+	pbv2.Severity_SEVERITY_FATAL: "fatal",
+}
+
+func recordsFromV1(logs []*pb.LogEntry) []LogRecord {
+	records := make([]LogRecord, len(logs))
+	for i, log := range logs {
+		records[i] = LogRecord{
+			Timestamp: log.GetTimestamp(),
+			Message:   log.GetMessage(),
+		}
+	}
+	return records
+}
+
+// recordsFromV2 converts v2 log entries. Trace and span IDs that are not
+// valid W3C trace context IDs are dropped rather than failing the batch.
+func recordsFromV2(logs []*pbv2.LogEntry) ([]LogRecord, error) {
+	records := make([]LogRecord, len(logs))
+	for i, log := range logs {
+		if len(log.GetAttributes()) > MaxLogAttributes {
+			return nil, fmt.Errorf("log entry %d has more than %d attributes", i, MaxLogAttributes)
+		}
+		records[i] = LogRecord{
+			Timestamp:  log.GetTimestamp(),
+			Message:    log.GetMessage(),
+			Level:      severityLevels[log.GetSeverity()],
+			Attributes: log.GetAttributes(),
+			Logger:     log.GetLogger(),
+			Hostname:   log.GetHostname(),
+			Instance:   log.GetInstance(),
+			TraceID:    normalizeTraceContextID(log.GetTraceId(), 16),
+			SpanID:     normalizeTraceContextID(log.GetSpanId(), 8),
+		}
+	}
+	return records, nil
+}
+
+// normalizeTraceContextID returns id in lowercase if it is the hex encoding
+// of size non-zero bytes, and an empty string otherwise.
+func normalizeTraceContextID(id string, size int) string {
+	id = strings.ToLower(id)
+	decoded, err := hex.DecodeString(id)
+	if err != nil || len(decoded) != size {
+		return ""
+	}
+	for _, b := range decoded {
+		if b != 0 {
+			return id
+		}
+	}
+	return ""
+}
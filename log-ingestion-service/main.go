@@ -6,6 +6,7 @@ import (
 
 	"log-ingestion-service/internal"
 	pb "log-ingestion-service/proto"
+	pbv2 "log-ingestion-service/proto/v2"
 	"net"
 	"os"
 	"os/signal"
@@ -41,6 +42,22 @@ func (s *Server) IngestLogBatch(ctx context.Context, req *pb.IngestEventRequest)
 	return &pb.IngestEventResponse{Success: true}, nil
 }
 
+// ServerV2 serves the v2 ingestion API, whose log entries also carry a
+// severity, attributes, the logger, host and trace context.
+type ServerV2 struct {
+	pbv2.UnimplementedIngestionServiceServer
+	processor *internal.Processor
+}
+
+func (s *ServerV2) IngestLogBatch(ctx context.Context, req *pbv2.IngestEventRequest) (*pbv2.IngestEventResponse, error) {
+	err := s.processor.ProcessLogsV2(ctx, req)
+	if err != nil {
+		log.Printf("Error processing logs: %v", err)
+		return &pbv2.IngestEventResponse{Success: false}, err
+	}
+	return &pbv2.IngestEventResponse{Success: true}, nil
+}
+
 func (s *Server) Close() error {
 	if s.processor != nil {
 		return s.processor.Close()
@@ -62,6 +79,7 @@ func main() {
 
 	grpcServer := grpc.NewServer()
 	pb.RegisterIngestionServiceServer(grpcServer, server)
+	pbv2.RegisterIngestionServiceServer(grpcServer, &ServerV2{processor: server.processor})
 
 	go func() {
 		log.Printf("GRPC server listening on %v", listener.Addr())
@@ -8,6 +8,7 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -51,6 +52,10 @@ func NewDatabase() (*Database, error) {
 
 	log.Println("Connected to TimescaleDB successfully")
 
+	if err := db.AutoMigrate(&ProcessedNotification{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate processed notifications: %w", err)
+	}
+
 	return &Database{DB: db}, nil
 }
 
@@ -106,7 +111,7 @@ func (d *Database) CreateQuickFixes(downtimeID, productID uint, quickFixes []Qui
 
 func (d *Database) GetActiveDowntime(productID uint) (*Downtime, error) {
 	var downtime Downtime
-	err := d.DB.Where("product_id = ? AND end_time IS NULL", productID).
+	err := d.DB.Where("product_id = ? AND incident_type = 'down' AND end_time IS NULL", productID).
 		First(&downtime).Error
 
 	if err != nil {
@@ -116,6 +121,34 @@ func (d *Database) GetActiveDowntime(productID uint) (*Downtime, error) {
 	return &downtime, nil
 }
 
+// IsEventProcessed reports whether the event was handled before, i.e. the
+// message is a redelivery.
+func (d *Database) IsEventProcessed(eventID string) (bool, error) {
+	var count int64
+	if err := d.DB.Model(&ProcessedNotification{}).Where("event_id = ?", eventID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up event %s: %w", eventID, err)
+	}
+	return count > 0, nil
+}
+
+// MarkEventProcessed records the event as handled.
+func (d *Database) MarkEventProcessed(eventID string) error {
+	err := d.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&ProcessedNotification{
+		EventID:     eventID,
+		ProcessedAt: time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark event %s as processed: %w", eventID, err)
+	}
+	return nil
+}
+
+// PruneProcessedEvents forgets events processed before the given time.
+func (d *Database) PruneProcessedEvents(before time.Time) (int64, error) {
+	result := d.DB.Where("processed_at < ?", before).Delete(&ProcessedNotification{})
+	return result.RowsAffected, result.Error
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
@@ -75,11 +75,33 @@ func (ec *EmailClient) SendEmail(toEmail, subject, body string) error {
 	return nil
 }
 
-func (ec *EmailClient) FormatServiceDownEmail(serviceName string, analysis *AnalysisResult) (string, string) {
+// formatComponents renders components as "db: fail (timeout), cache: pass",
+// failing ones first as sent by the ping service.
+func formatComponents(components []ComponentStatus) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		parts[i] = fmt.Sprintf("%s: %s", c.Name, c.Status)
+		if c.Output != "" && c.Status != "pass" {
+			parts[i] += " (" + c.Output + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (ec *EmailClient) FormatServiceDownEmail(serviceName, failureReason string, components []ComponentStatus, dependents []string, analysis *AnalysisResult) (string, string) {
 	subject := fmt.Sprintf("🚨 ALERT: %s is DOWN", serviceName)
 
 	var body strings.Builder
 	body.WriteString(fmt.Sprintf("Service Alert: %s is currently DOWN\n\n", serviceName))
+	if failureReason != "" {
+		body.WriteString(fmt.Sprintf("Failed Check: %s\n\n", failureReason))
+	}
+	if len(components) > 0 {
+		body.WriteString(fmt.Sprintf("Components: %s\n\n", formatComponents(components)))
+	}
+	if len(dependents) > 0 {
+		body.WriteString(fmt.Sprintf("Also Affected: %s depend on this service. Their alerts are held back until it recovers.\n\n", strings.Join(dependents, ", ")))
+	}
 	body.WriteString(fmt.Sprintf("Issue Analysis: %s\n\n", analysis.Summary))
 	body.WriteString("Recommended Quick Fixes:\n\n")
 
@@ -117,3 +139,72 @@ func (ec *EmailClient) FormatServiceUpEmail(serviceName string, downtimeDuration
 
 	return subject, body
 }
+
+func (ec *EmailClient) FormatServiceDegradedEmail(serviceName, reason string) (string, string) {
+	subject := fmt.Sprintf("⚠️ WARNING: %s is DEGRADED", serviceName)
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Service Warning: %s is responding slowly\n\n", serviceName))
+	if reason != "" {
+		body.WriteString(fmt.Sprintf("Details: %s\n\n", reason))
+	}
+	body.WriteString("The service is still up, but its response times are above the configured threshold. ")
+	body.WriteString("Investigate now to avoid an outage.\n\n")
+	body.WriteString("---\n")
+	body.WriteString("This warning was generated by OpsBuddy Monitoring System")
+
+	return subject, body.String()
+}
+
+func (ec *EmailClient) FormatServiceFlappingEmail(serviceName, reason string) (string, string) {
+	subject := fmt.Sprintf("⚠️ WARNING: %s is FLAPPING", serviceName)
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Service Warning: %s keeps switching between up and down\n\n", serviceName))
+	if reason != "" {
+		body.WriteString(fmt.Sprintf("Details: %s\n\n", reason))
+	}
+	body.WriteString("Individual down and recovery alerts are paused while the service is flapping. ")
+	body.WriteString("You will get one more notification once it has been stable for the flap window.\n\n")
+	body.WriteString("---\n")
+	body.WriteString("This warning was generated by OpsBuddy Monitoring System")
+
+	return subject, body.String()
+}
+
+func (ec *EmailClient) FormatServiceFlappingEndedEmail(serviceName, message string) (string, string) {
+	subject := fmt.Sprintf("ℹ️ UPDATE: %s stopped flapping", serviceName)
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Service Update: %s is stable again\n\n", serviceName))
+	if message != "" {
+		body.WriteString(fmt.Sprintf("%s\n\n", message))
+	}
+	body.WriteString("Down and recovery alerts are active again.\n\n")
+	body.WriteString("---\n")
+	body.WriteString("This notification was generated by OpsBuddy Monitoring System")
+
+	return subject, body.String()
+}
+
+func (ec *EmailClient) FormatCertExpiringEmail(serviceName string, cert *CertificateDetails) (string, string) {
+	subject := fmt.Sprintf("⚠️ WARNING: TLS certificate for %s expires in %d days", serviceName, cert.DaysLeft)
+	if cert.DaysLeft <= 0 {
+		subject = fmt.Sprintf("🚨 ALERT: TLS certificate for %s has EXPIRED", serviceName)
+	}
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Certificate Warning: the TLS certificate served by %s is about to expire\n\n", serviceName))
+	body.WriteString(fmt.Sprintf("Expires At: %s\n", cert.ExpiresAt.Format("2006-01-02 15:04:05 MST")))
+	body.WriteString(fmt.Sprintf("Days Left: %d\n", cert.DaysLeft))
+	body.WriteString(fmt.Sprintf("Subject: %s\n", cert.Subject))
+	body.WriteString(fmt.Sprintf("Issuer: %s\n", cert.Issuer))
+	if len(cert.SANs) > 0 {
+		body.WriteString(fmt.Sprintf("Names: %s\n", strings.Join(cert.SANs, ", ")))
+	}
+	body.WriteString("\nRenew and deploy a new certificate before it expires to avoid an outage.\n\n")
+	body.WriteString("---\n")
+	body.WriteString("This warning was generated by OpsBuddy Monitoring System")
+
+	return subject, body.String()
+}
@@ -47,7 +47,7 @@ func NewLLMClient() *LLMClient {
 	}
 }
 
-func (llm *LLMClient) AnalyzeLogs(ctx context.Context, logs []Log, serviceName, serviceDescription string) (*AnalysisResult, error) {
+func (llm *LLMClient) AnalyzeLogs(ctx context.Context, logs []Log, serviceName, serviceDescription, failureReason string, components []ComponentStatus) (*AnalysisResult, error) {
 	if llm.client == nil {
 		log.Printf("Gemini client not available, using mock analysis")
 		return llm.getMockAnalysis(serviceName), nil
@@ -62,6 +62,12 @@ func (llm *LLMClient) AnalyzeLogs(ctx context.Context, logs []Log, serviceName,
 	if serviceDescription != "" {
 		serviceContext += fmt.Sprintf("\nService Description: %s", serviceDescription)
 	}
+	if failureReason != "" {
+		serviceContext += fmt.Sprintf("\nHealth Check Failure: %s", failureReason)
+	}
+	if len(components) > 0 {
+		serviceContext += fmt.Sprintf("\nComponent Status (from the service's health endpoint): %s", formatComponents(components))
+	}
 
 	prompt := fmt.Sprintf(`You are an expert DevOps engineer analyzing logs from a failed service. Analyze the logs and provide actionable quick fixes.
 
@@ -47,7 +47,9 @@ type Downtime struct {
 	StartTime          time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"start_time"`
 	EndTime            *time.Time        `json:"end_time,omitempty"`
 	Status             string            `gorm:"size:50;not null;default:'down'" json:"status"`
+	IncidentType       string            `gorm:"size:20;not null;default:'down';index" json:"incident_type"` // "down", "degraded", "flapping"
 	IsNotificationSent bool              `gorm:"not null;default:false" json:"is_notification_sent"`
+	FailureReason      string            `gorm:"type:text" json:"failure_reason,omitempty"`
 	QuickFixes         []ProductQuickFix `gorm:"constraint:OnDelete:CASCADE;" json:"quick_fixes,omitempty"`
 }
 
@@ -76,10 +78,45 @@ func (Downtime) TableName() string { return "downtimes" }
 
 func (ProductQuickFix) TableName() string { return "quick_fixes" }
 
+// ProcessedNotification marks an event as handled so that redeliveries of
+// the same EventID are skipped. It is written after the alert was sent.
+type ProcessedNotification struct {
+	EventID     string    `gorm:"primaryKey;size:36"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+func (ProcessedNotification) TableName() string { return "processed_notifications" }
+
 type NotificationEvent struct {
+	EventID   string    `json:"event_id"` // empty for events from producers without an outbox
 	ProductID uint      `json:"product_id"`
 	UserEmail string    `json:"user_email"`
 	Timestamp time.Time `json:"timestamp"`
-	EventType string    `json:"event_type"` // "service_down", "service_up"
+	EventType string    `json:"event_type"` // "service_down", "service_up", "service_degraded", "service_flapping", "service_flapping_ended", "cert_expiring"
 	Message   string    `json:"message"`
+	Reason    string    `json:"reason,omitempty"`
+
+	// Components is the health document of the failed check, e.g. which
+	// database or cache the service reported as broken.
+	Components []ComponentStatus `json:"components,omitempty"`
+
+	// Dependents are the products that depend on the failed one; their own
+	// down alerts are held back while it is down.
+	Dependents []string `json:"dependents,omitempty"`
+
+	Certificate *CertificateDetails `json:"certificate,omitempty"`
+}
+
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "pass", "warn", "fail"
+	Output string `json:"output,omitempty"`
+}
+
+type CertificateDetails struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans"`
+	ExpiresAt time.Time `json:"expires_at"`
+	DaysLeft  int       `json:"days_left"`
 }
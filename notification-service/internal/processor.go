@@ -21,7 +21,34 @@ func NewNotificationProcessor(db *Database) *NotificationProcessor {
 	}
 }
 
+// ProcessNotification handles an event once per EventID. The ping-service
+// outbox delivers at least once, so redeliveries are expected. The event is
+// marked processed only after it was handled: a crash in between sends the
+// alert twice on redelivery rather than not at all.
 func (np *NotificationProcessor) ProcessNotification(ctx context.Context, event NotificationEvent) error {
+	if event.EventID == "" {
+		return np.processNotification(ctx, event)
+	}
+
+	processed, err := np.db.IsEventProcessed(event.EventID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		log.Printf("Skipping already processed notification %s", event.EventID)
+		return nil
+	}
+
+	if err := np.processNotification(ctx, event); err != nil {
+		return err
+	}
+	if err := np.db.MarkEventProcessed(event.EventID); err != nil {
+		log.Printf("Notification %s was sent but may be sent again on redelivery: %v", event.EventID, err)
+	}
+	return nil
+}
+
+func (np *NotificationProcessor) processNotification(ctx context.Context, event NotificationEvent) error {
 	log.Printf("Processing notification: ProductID=%d, EventType=%s", event.ProductID, event.EventType)
 
 	product, err := np.db.GetProductWithUser(event.ProductID)
@@ -34,6 +61,14 @@ func (np *NotificationProcessor) ProcessNotification(ctx context.Context, event
 		return np.handleServiceDown(ctx, event, product)
 	case "service_up":
 		return np.handleServiceUp(ctx, event, product)
+	case "service_degraded":
+		return np.handleServiceDegraded(ctx, event, product)
+	case "service_flapping":
+		return np.handleServiceFlapping(ctx, event, product)
+	case "service_flapping_ended":
+		return np.handleServiceFlappingEnded(ctx, event, product)
+	case "cert_expiring":
+		return np.handleCertExpiring(ctx, event, product)
 	default:
 		log.Printf("Unknown event type: %s", event.EventType)
 		return nil
@@ -65,7 +100,7 @@ func (np *NotificationProcessor) handleServiceDown(ctx context.Context, event No
 	}
 
 	log.Printf("Starting LLM analysis for service: %s with %d logs", product.Name, len(logs))
-	analysis, err := np.llmClient.AnalyzeLogs(ctx, logs, product.Name, product.Description)
+	analysis, err := np.llmClient.AnalyzeLogs(ctx, logs, product.Name, product.Description, event.Reason, event.Components)
 	if err != nil {
 		log.Printf("Warning: LLM analysis failed: %v", err)
 		analysis = &AnalysisResult{
@@ -127,7 +162,7 @@ func (np *NotificationProcessor) handleServiceDown(ctx context.Context, event No
 	}
 
 	if userEmail != "" {
-		subject, body := np.emailClient.FormatServiceDownEmail(product.Name, analysis)
+		subject, body := np.emailClient.FormatServiceDownEmail(product.Name, event.Reason, event.Components, event.Dependents, analysis)
 
 		if err := np.emailClient.SendEmail(userEmail, subject, body); err != nil {
 			log.Printf("Failed to send email to user %s: %v", product.User.Username, err)
@@ -185,6 +220,131 @@ func (np *NotificationProcessor) handleServiceUp(ctx context.Context, event Noti
 	return nil
 }
 
+func (np *NotificationProcessor) handleServiceDegraded(ctx context.Context, event NotificationEvent, product *Product) error {
+	log.Printf("Handling service degradation for product: %s (ID: %d)", product.Name, product.ID)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	userEmail := event.UserEmail
+	if userEmail == "" {
+		userEmail = product.User.Email
+	}
+
+	if userEmail != "" {
+		subject, body := np.emailClient.FormatServiceDegradedEmail(product.Name, event.Reason)
+
+		if err := np.emailClient.SendEmail(userEmail, subject, body); err != nil {
+			log.Printf("Failed to send degraded email to user %s: %v", product.User.Username, err)
+			return fmt.Errorf("failed to send degraded email notification: %w", err)
+		}
+
+		log.Printf("Degraded email notification sent to user %s (%s) for service %s", product.User.Username, userEmail, product.Name)
+	} else {
+		log.Printf("No email configured for user %s, skipping degraded notification", product.User.Username)
+	}
+
+	return nil
+}
+
+func (np *NotificationProcessor) handleServiceFlapping(ctx context.Context, event NotificationEvent, product *Product) error {
+	log.Printf("Handling service flapping for product: %s (ID: %d)", product.Name, product.ID)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	userEmail := event.UserEmail
+	if userEmail == "" {
+		userEmail = product.User.Email
+	}
+
+	if userEmail != "" {
+		subject, body := np.emailClient.FormatServiceFlappingEmail(product.Name, event.Reason)
+
+		if err := np.emailClient.SendEmail(userEmail, subject, body); err != nil {
+			log.Printf("Failed to send flapping email to user %s: %v", product.User.Username, err)
+			return fmt.Errorf("failed to send flapping email notification: %w", err)
+		}
+
+		log.Printf("Flapping email notification sent to user %s (%s) for service %s", product.User.Username, userEmail, product.Name)
+	} else {
+		log.Printf("No email configured for user %s, skipping flapping notification", product.User.Username)
+	}
+
+	return nil
+}
+
+func (np *NotificationProcessor) handleServiceFlappingEnded(ctx context.Context, event NotificationEvent, product *Product) error {
+	log.Printf("Handling end of flapping for product: %s (ID: %d)", product.Name, product.ID)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	userEmail := event.UserEmail
+	if userEmail == "" {
+		userEmail = product.User.Email
+	}
+
+	if userEmail != "" {
+		subject, body := np.emailClient.FormatServiceFlappingEndedEmail(product.Name, event.Message)
+
+		if err := np.emailClient.SendEmail(userEmail, subject, body); err != nil {
+			log.Printf("Failed to send flapping ended email to user %s: %v", product.User.Username, err)
+			return fmt.Errorf("failed to send flapping ended email notification: %w", err)
+		}
+
+		log.Printf("Flapping ended email notification sent to user %s (%s) for service %s", product.User.Username, userEmail, product.Name)
+	} else {
+		log.Printf("No email configured for user %s, skipping flapping ended notification", product.User.Username)
+	}
+
+	return nil
+}
+
+func (np *NotificationProcessor) handleCertExpiring(ctx context.Context, event NotificationEvent, product *Product) error {
+	log.Printf("Handling certificate expiry warning for product: %s (ID: %d)", product.Name, product.ID)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if event.Certificate == nil {
+		log.Printf("Certificate expiry event for product %d has no certificate details, skipping", product.ID)
+		return nil
+	}
+
+	userEmail := event.UserEmail
+	if userEmail == "" {
+		userEmail = product.User.Email
+	}
+
+	if userEmail != "" {
+		subject, body := np.emailClient.FormatCertExpiringEmail(product.Name, event.Certificate)
+
+		if err := np.emailClient.SendEmail(userEmail, subject, body); err != nil {
+			log.Printf("Failed to send certificate expiry email to user %s: %v", product.User.Username, err)
+			return fmt.Errorf("failed to send certificate expiry email notification: %w", err)
+		}
+
+		log.Printf("Certificate expiry email sent to user %s (%s) for service %s", product.User.Username, userEmail, product.Name)
+	} else {
+		log.Printf("No email configured for user %s, skipping certificate expiry notification", product.User.Username)
+	}
+
+	return nil
+}
+
 // // TestLLMIntegration tests the LLM integration with sample data
 // func (np *NotificationProcessor) TestLLMIntegration(ctx context.Context, productID uint) error {
 // 	log.Printf("Testing LLM integration for product ID: %d", productID)
@@ -208,7 +368,7 @@ func (np *NotificationProcessor) handleServiceUp(ctx context.Context, event Noti
 // 	}
 
 // 	// Test LLM analysis
-// 	analysis, err := np.llmClient.AnalyzeLogs(ctx, logs, product.Name, product.Description)
+// 	analysis, err := np.llmClient.AnalyzeLogs(ctx, logs, product.Name, product.Description, event.Reason, nil)
 // 	if err != nil {
 // 		return fmt.Errorf("LLM analysis failed: %w", err)
 // 	}
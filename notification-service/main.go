@@ -15,6 +15,9 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 )
 
+// processedEventRetention must outlast the ping-service outbox retention.
+const processedEventRetention = 30 * 24 * time.Hour
+
 func validateEnvironment() error {
 	requiredVars := []string{
 		"KAFKA_BROKERS",
@@ -123,6 +126,12 @@ func main() {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pruneProcessedEvents(ctx, db)
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -150,3 +159,24 @@ func main() {
 
 	log.Println("Notification service shutdown complete")
 }
+
+// pruneProcessedEvents forgets processed event IDs once redeliveries are no
+// longer expected.
+func pruneProcessedEvents(ctx context.Context, db *internal.Database) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := db.PruneProcessedEvents(time.Now().Add(-processedEventRetention))
+			if err != nil {
+				log.Printf("Failed to prune processed notifications: %v", err)
+			} else if removed > 0 {
+				log.Printf("Pruned %d processed notifications", removed)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
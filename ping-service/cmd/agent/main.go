@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"os"
+	"os/signal"
+	"ping-service/internal"
+	"strconv"
+	"syscall"
+
+	_ "github.com/joho/godotenv/autoload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	defaultServerAddr  = "localhost:50052"
+	defaultWorkerCount = 5
+)
+
+// The agent checks products assigned to its token from inside a private
+// network and reports the results to the ping-service over gRPC.
+func main() {
+	token := os.Getenv("AGENT_TOKEN")
+	if token == "" {
+		log.Fatal("AGENT_TOKEN is required")
+	}
+
+	serverAddr := os.Getenv("AGENT_SERVER_ADDR")
+	if serverAddr == "" {
+		serverAddr = defaultServerAddr
+	}
+
+	workerCount := defaultWorkerCount
+	if value := os.Getenv("AGENT_WORKERS"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			log.Fatalf("Invalid AGENT_WORKERS %q: expected a positive integer", value)
+		}
+		workerCount = count
+	}
+
+	// The token and the check configs are sent to the server, so plaintext
+	// is only allowed against a server on this machine
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := os.Getenv("AGENT_TLS_CA"); caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			log.Fatalf("Failed to read AGENT_TLS_CA: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			log.Fatalf("AGENT_TLS_CA %s contains no PEM certificates", caFile)
+		}
+	}
+	creds := credentials.NewTLS(tlsConfig)
+	if os.Getenv("AGENT_INSECURE") == "true" {
+		if !internal.IsLoopbackAddr(serverAddr) {
+			log.Fatalf("AGENT_INSECURE is only allowed for a server on a loopback address, not %s", serverAddr)
+		}
+		creds = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("Failed to create gRPC client for %s: %v", serverAddr, err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Printf("Error closing gRPC connection: %v", err)
+		}
+	}()
+
+	agent := internal.NewAgentRunner(conn, token, workerCount)
+	if err := agent.Start(); err != nil {
+		log.Fatalf("Failed to start agent: %v", err)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	log.Printf("Agent is running against %s. Press Ctrl+C to stop.", serverAddr)
+	<-sigChan
+
+	log.Println("Received shutdown signal")
+	agent.Stop()
+	log.Println("Agent shutdown complete")
+}
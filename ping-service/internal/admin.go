@@ -0,0 +1,274 @@
+package internal
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const adminShutdownTimeout = 5 * time.Second
+
+// AdminServer exposes the scheduler state of this instance over HTTP for
+// debugging missed or late detections: the heap, worker saturation, the
+// last result per product and an endpoint that checks a product right away.
+// With a token configured every request needs it as a bearer token.
+type AdminServer struct {
+	ps     *PingService
+	addr   string
+	token  string
+	server *http.Server
+}
+
+// adminItem is the JSON view of a scheduled product. Headers and TLS
+// settings are left out since they may carry credentials.
+type adminItem struct {
+	ProductID   uint         `json:"product_id"`
+	HealthAPI   string       `json:"health_api"`
+	CheckType   string       `json:"check_type"`
+	Interval    string       `json:"interval"`
+	Checking    bool         `json:"checking"`
+	NextPingAt  *time.Time   `json:"next_ping_at,omitempty"`
+	RetryCount  int          `json:"retry_count"`
+	MaxRetries  int          `json:"max_retries"`
+	IsDown      bool         `json:"is_down"`
+	LastFailure string       `json:"last_failure,omitempty"`
+	IsDegraded  bool         `json:"is_degraded"`
+	IsFlapping  bool         `json:"is_flapping"`
+	LastResult  *CheckResult `json:"last_result,omitempty"`
+}
+
+func NewAdminServer(ps *PingService, addr, token string) *AdminServer {
+	return &AdminServer{
+		ps:    ps,
+		addr:  addr,
+		token: token,
+	}
+}
+
+func (as *AdminServer) Start() error {
+	listener, err := net.Listen("tcp", as.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", as.addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/heap", as.handleHeap)
+	mux.HandleFunc("GET /admin/workers", as.handleWorkers)
+	mux.HandleFunc("GET /admin/products/{id}", as.handleProduct)
+	mux.HandleFunc("POST /admin/products/{id}/check", as.handleCheckNow)
+
+	as.server = &http.Server{
+		Handler:           as.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := as.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Admin HTTP server stopped: %v", err)
+		}
+	}()
+
+	if as.token == "" {
+		log.Printf("Admin HTTP server listening on %s without authentication", as.addr)
+	} else {
+		log.Printf("Admin HTTP server listening on %s", as.addr)
+	}
+	return nil
+}
+
+func (as *AdminServer) Stop() {
+	if as.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+	defer cancel()
+	if err := as.server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down admin HTTP server: %v", err)
+	}
+}
+
+func (as *AdminServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if as.token != "" {
+			token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(token), []byte(as.token)) != 1 {
+				writeAdminError(w, http.StatusUnauthorized, "invalid or missing admin token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHeap lists every product this instance schedules, the ones being
+// checked first and the rest by their next check.
+func (as *AdminServer) handleHeap(w http.ResponseWriter, r *http.Request) {
+	snapshots := as.ps.heap.SafeSnapshot()
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Checking != snapshots[j].Checking {
+			return snapshots[i].Checking
+		}
+		return snapshots[i].NextPingAt.Before(snapshots[j].NextPingAt)
+	})
+
+	items := make([]adminItem, len(snapshots))
+	for i, snapshot := range snapshots {
+		items[i] = as.view(snapshot)
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+		"count": len(items),
+		"items": items,
+	})
+}
+
+func (as *AdminServer) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+		"mode":    as.ps.mode,
+		"pool":    as.ps.workerPool.Stats(),
+		"waiting": as.ps.heap.SafeLen(),
+	})
+}
+
+func (as *AdminServer) handleProduct(w http.ResponseWriter, r *http.Request) {
+	productID, ok := adminProductID(w, r)
+	if !ok {
+		return
+	}
+
+	if snapshot, ok := as.lookup(productID); ok {
+		writeAdminJSON(w, http.StatusOK, as.view(snapshot))
+		return
+	}
+
+	// Products checked by an agent or another replica still have results
+	if result, ok := as.ps.resultWriter.Last(productID); ok {
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+			"product_id":  productID,
+			"scheduled":   false,
+			"last_result": result,
+		})
+		return
+	}
+	writeAdminError(w, http.StatusNotFound, "product is not scheduled on this instance")
+}
+
+// handleCheckNow takes the product out of the heap, has a worker check it
+// through the regular pipeline and responds with the outcome once the check
+// has been recorded. Failures count towards the retries like any other check.
+func (as *AdminServer) handleCheckNow(w http.ResponseWriter, r *http.Request) {
+	productID, ok := adminProductID(w, r)
+	if !ok {
+		return
+	}
+
+	item := as.ps.heap.SafeTake(productID)
+	if item == nil {
+		if as.ps.heap.SafeContains(productID) {
+			writeAdminError(w, http.StatusConflict, "product is being checked, try again")
+			return
+		}
+		writeAdminError(w, http.StatusNotFound, "product is not scheduled on this instance")
+		return
+	}
+
+	checked := make(chan *CheckOutcome, 1)
+	item.checked = checked
+	if !as.ps.workerPool.SubmitJob(item) {
+		item.checked = nil
+		as.ps.heap.SafePush(item)
+		writeAdminError(w, http.StatusServiceUnavailable, "ping service is shutting down")
+		return
+	}
+
+	select {
+	case outcome := <-checked:
+		if outcome == nil {
+			writeAdminError(w, http.StatusConflict, "product is no longer scheduled on this instance")
+			return
+		}
+		response := map[string]interface{}{
+			"product_id":  productID,
+			"success":     outcome.Success,
+			"status_code": outcome.StatusCode,
+			"reason":      outcome.Reason,
+			"timings": map[string]float64{
+				"dns_ms":     durationMs(outcome.Timings.DNS),
+				"connect_ms": durationMs(outcome.Timings.Connect),
+				"tls_ms":     durationMs(outcome.Timings.TLS),
+				"ttfb_ms":    durationMs(outcome.Timings.TTFB),
+				"total_ms":   durationMs(outcome.Timings.Total),
+			},
+		}
+		if snapshot, ok := as.lookup(productID); ok {
+			response["item"] = as.view(snapshot)
+		}
+		writeAdminJSON(w, http.StatusOK, response)
+	case <-r.Context().Done():
+		// The check still runs and is recorded
+	case <-as.ps.ctx.Done():
+		writeAdminError(w, http.StatusServiceUnavailable, "ping service is shutting down")
+	}
+}
+
+func (as *AdminServer) lookup(productID uint) (PingItemSnapshot, bool) {
+	for _, snapshot := range as.ps.heap.SafeSnapshot() {
+		if snapshot.ProductID == productID {
+			return snapshot, true
+		}
+	}
+	return PingItemSnapshot{}, false
+}
+
+func (as *AdminServer) view(snapshot PingItemSnapshot) adminItem {
+	item := adminItem{
+		ProductID:  snapshot.ProductID,
+		HealthAPI:  snapshot.HealthAPI,
+		CheckType:  snapshot.Config.Type,
+		Interval:   snapshot.Config.Interval.String(),
+		Checking:   snapshot.Checking,
+		MaxRetries: snapshot.Config.MaxRetries,
+	}
+	if !snapshot.Checking {
+		nextPingAt := snapshot.NextPingAt
+		item.NextPingAt = &nextPingAt
+		item.RetryCount = snapshot.RetryCount
+		item.IsDown = snapshot.IsDown
+		item.LastFailure = snapshot.LastFailure
+		item.IsDegraded = snapshot.IsDegraded
+		item.IsFlapping = snapshot.IsFlapping
+	}
+	if result, ok := as.ps.resultWriter.Last(snapshot.ProductID); ok {
+		item.LastResult = &result
+	}
+	return item
+}
+
+func adminProductID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid product ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write admin response: %v", err)
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"ping-service/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	AgentVersion = "1"
+
+	agentReportBufferSize = 1000
+	agentReconnectDelay   = 5 * time.Second
+)
+
+// AgentRunner is the remote probe side of the agent protocol. It polls the
+// products assigned to its token, checks them with the same checkers and
+// worker pool as the ping-service and streams every result back. Downtime
+// decisions are left to the ping-service.
+type AgentRunner struct {
+	client       proto.AgentServiceClient
+	token        string
+	heap         *PingHeap
+	workerPool   *WorkerPool
+	checkers     map[string]Checker
+	reports      chan *proto.CheckReport
+	pollInterval time.Duration
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+
+	mutex   sync.Mutex
+	dropped int
+}
+
+func NewAgentRunner(conn grpc.ClientConnInterface, token string, workerCount int) *AgentRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ar := &AgentRunner{
+		client:       proto.NewAgentServiceClient(conn),
+		token:        token,
+		heap:         NewPingHeap(),
+		checkers:     newCheckers(),
+		reports:      make(chan *proto.CheckReport, agentReportBufferSize),
+		pollInterval: DefaultAgentPollInterval,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	ar.workerPool = NewWorkerPool(workerCount, ar.processPing)
+	return ar
+}
+
+func (ar *AgentRunner) Start() error {
+	log.Println("Starting agent...")
+
+	if err := ar.syncAssignments(); err != nil {
+		return fmt.Errorf("failed to fetch assignments: %w", err)
+	}
+
+	ar.workerPool.Start()
+
+	ar.wg.Add(1)
+	go ar.streamReports()
+
+	go runScheduler(ar.ctx, ar.heap, ar.workerPool)
+
+	go ar.pollAssignments()
+
+	log.Println("Agent started successfully")
+	return nil
+}
+
+// Stop waits for running checks and tries to deliver their reports before
+// returning.
+func (ar *AgentRunner) Stop() {
+	log.Println("Stopping agent...")
+	ar.cancel()
+	ar.workerPool.Stop()
+	close(ar.reports)
+	ar.wg.Wait()
+	log.Println("Agent stopped")
+}
+
+func (ar *AgentRunner) pollAssignments() {
+	timer := time.NewTimer(ar.pollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := ar.syncAssignments(); err != nil {
+				log.Printf("Failed to fetch assignments: %v", err)
+			}
+			timer.Reset(ar.pollInterval)
+		case <-ar.ctx.Done():
+			return
+		}
+	}
+}
+
+// syncAssignments brings the heap in line with the assigned products, the
+// same way reconcileProducts does for the ping-service.
+func (ar *AgentRunner) syncAssignments() error {
+	resp, err := ar.client.GetAssignments(ar.authContext(ar.ctx), &proto.GetAssignmentsRequest{
+		AgentVersion: AgentVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.GetPollIntervalMs() > 0 {
+		ar.pollInterval = time.Duration(resp.GetPollIntervalMs()) * time.Millisecond
+	}
+
+	versions := ar.heap.SafeVersions()
+	now := time.Now()
+	var added, updated, removed int
+
+	for _, assignment := range resp.GetAssignments() {
+		item, err := assignmentItem(assignment, now)
+		if err != nil {
+			log.Printf("Skipping assignment for product %d: %v", assignment.GetProductId(), err)
+			continue
+		}
+
+		version, tracked := versions[item.ProductID]
+		delete(versions, item.ProductID)
+
+		switch {
+		case !tracked:
+			if ar.heap.SafeAdd(item) {
+				added++
+			}
+		case !version.Equal(item.Version):
+			ar.heap.SafeUpsert(item)
+			updated++
+		}
+	}
+
+	for productID := range versions {
+		if ar.heap.SafeRemove(productID) {
+			removed++
+		}
+	}
+
+	if added+updated+removed > 0 {
+		log.Printf("Synced %d assignments (%d added, %d updated, %d removed)",
+			len(resp.GetAssignments()), added, updated, removed)
+	}
+	return nil
+}
+
+func assignmentItem(assignment *proto.Assignment, now time.Time) (*PingItem, error) {
+	product := Product{
+		ID:        uint(assignment.GetProductId()),
+		HealthAPI: assignment.GetHealthApi(),
+	}
+	if assignment.GetCheckConfig() != "" {
+		var config CheckConfig
+		if err := json.Unmarshal([]byte(assignment.GetCheckConfig()), &config); err != nil {
+			return nil, fmt.Errorf("invalid check config: %w", err)
+		}
+		product.CheckConfig = &config
+	}
+
+	item, err := newPingItem(product, now)
+	if err != nil {
+		return nil, err
+	}
+	item.Version = time.Unix(0, assignment.GetVersion())
+	return item, nil
+}
+
+// processPing checks an item and reschedules it with the ping-service's
+// retry and backoff rules. Certificates are not monitored for agent checks.
+func (ar *AgentRunner) processPing(item *PingItem) {
+	checkedAt := time.Now()
+	outcome := runCheck(ar.ctx, ar.checkers, item)
+	ar.enqueue(&proto.CheckReport{
+		ProductId:       uint32(item.ProductID),
+		Success:         outcome.Success,
+		StatusCode:      int32(outcome.StatusCode),
+		Reason:          outcome.Reason,
+		CheckedAtUnixMs: checkedAt.UnixMilli(),
+		DnsMs:           durationMs(outcome.Timings.DNS),
+		ConnectMs:       durationMs(outcome.Timings.Connect),
+		TlsMs:           durationMs(outcome.Timings.TLS),
+		TtfbMs:          durationMs(outcome.Timings.TTFB),
+		TotalMs:         durationMs(outcome.Timings.Total),
+	})
+
+	if outcome.Success {
+		item.RetryCount = 0
+		item.scheduleNext()
+	} else {
+		item.RetryCount++
+		log.Printf("Product %d health check failed (attempt %d/%d): %s", item.ProductID, item.RetryCount, item.Config.MaxRetries, outcome.Reason)
+		if item.RetryCount < item.Config.MaxRetries {
+			item.NextPingAt = time.Now().Add(item.Config.BackoffDelay(item.RetryCount))
+		} else {
+			item.RetryCount = 0
+			item.scheduleNext()
+		}
+	}
+
+	ar.heap.SafePush(item)
+}
+
+// enqueue never blocks a worker; reports are dropped and counted while the
+// buffer is full, e.g. during a long disconnect.
+func (ar *AgentRunner) enqueue(report *proto.CheckReport) {
+	select {
+	case ar.reports <- report:
+	default:
+		ar.mutex.Lock()
+		ar.dropped++
+		ar.mutex.Unlock()
+	}
+}
+
+// streamReports keeps a ReportResults stream open and reconnects when it
+// breaks. It returns once the report channel is closed and flushed, or when
+// the agent stops while disconnected.
+func (ar *AgentRunner) streamReports() {
+	defer ar.wg.Done()
+
+	var pending *proto.CheckReport
+	for {
+		var err error
+		pending, err = ar.sendReports(pending)
+		if err == nil {
+			return
+		}
+		log.Printf("Report stream failed: %v", err)
+
+		if ar.ctx.Err() != nil {
+			log.Printf("Dropping unsent reports on shutdown")
+			return
+		}
+		select {
+		case <-time.After(agentReconnectDelay):
+		case <-ar.ctx.Done():
+		}
+	}
+}
+
+// sendReports streams reports until the channel is closed. On failure it
+// returns the report that could not be sent so it is retried first.
+func (ar *AgentRunner) sendReports(pending *proto.CheckReport) (*proto.CheckReport, error) {
+	// The stream outlives ar.ctx so reports of the last checks still go out
+	stream, err := ar.client.ReportResults(ar.authContext(context.Background()))
+	if err != nil {
+		return pending, err
+	}
+
+	send := func(report *proto.CheckReport) error {
+		if err := stream.Send(report); err != nil {
+			if errors.Is(err, io.EOF) {
+				// The actual error is only available from the receive side
+				if _, recvErr := stream.CloseAndRecv(); recvErr != nil {
+					err = recvErr
+				}
+			}
+			return err
+		}
+		return nil
+	}
+
+	if pending != nil {
+		if err := send(pending); err != nil {
+			return pending, err
+		}
+	}
+
+	for report := range ar.reports {
+		ar.logDropped()
+		if err := send(report); err != nil {
+			return report, err
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Report stream closed, %d reports accepted", resp.GetAccepted())
+	return nil, nil
+}
+
+func (ar *AgentRunner) logDropped() {
+	ar.mutex.Lock()
+	dropped := ar.dropped
+	ar.dropped = 0
+	ar.mutex.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Dropped %d check reports because the report buffer was full", dropped)
+	}
+}
+
+func (ar *AgentRunner) authContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+ar.token)
+}
@@ -0,0 +1,386 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"ping-service/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultAgentPollInterval = 30 * time.Second
+
+	// agentLocationPrefix marks check results reported by an agent.
+	agentLocationPrefix = "agent:"
+)
+
+// AgentServer hands remote agents the products assigned to them and feeds
+// the results they stream back through recordOutcome, so downtimes of
+// private services are handled exactly like those checked centrally.
+// Agent tokens and check configs cross the network, so it serves TLS unless
+// plaintext is explicitly allowed on a loopback address.
+type AgentServer struct {
+	proto.UnimplementedAgentServiceServer
+
+	ps       *PingService
+	addr     string
+	certFile string
+	keyFile  string
+	insecure bool
+	server   *grpc.Server
+
+	mutex sync.Mutex
+	items map[uint]*agentItem
+}
+
+// agentItem is the central state of a product checked by an agent. Reports
+// for the same product are applied one at a time.
+type agentItem struct {
+	mutex   sync.Mutex
+	agentID uint
+	item    *PingItem
+}
+
+func NewAgentServer(ps *PingService, addr, certFile, keyFile string, insecure bool) *AgentServer {
+	return &AgentServer{
+		ps:       ps,
+		addr:     addr,
+		certFile: certFile,
+		keyFile:  keyFile,
+		insecure: insecure,
+		items:    make(map[uint]*agentItem),
+	}
+}
+
+func (as *AgentServer) Start() error {
+	options := []grpc.ServerOption{grpc.WaitForHandlers(true)}
+	switch {
+	case as.certFile != "" || as.keyFile != "":
+		certificate, err := tls.LoadX509KeyPair(as.certFile, as.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load agent TLS certificate: %w", err)
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		})))
+	case as.insecure && IsLoopbackAddr(as.addr):
+		log.Printf("Agent gRPC server on %s runs without TLS", as.addr)
+	default:
+		return fmt.Errorf("agent gRPC server on %s needs a TLS certificate; plaintext is only allowed on a loopback address", as.addr)
+	}
+
+	listener, err := net.Listen("tcp", as.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", as.addr, err)
+	}
+
+	as.server = grpc.NewServer(options...)
+	proto.RegisterAgentServiceServer(as.server, as)
+
+	go func() {
+		if err := as.server.Serve(listener); err != nil {
+			log.Printf("Agent gRPC server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Agent gRPC server listening on %s", as.addr)
+	return nil
+}
+
+// IsLoopbackAddr reports whether a host:port address refers to this machine
+// only, e.g. localhost:50052 or 127.0.0.1:50052.
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Stop cancels the open report streams and waits for their handlers, so no
+// result is recorded afterwards.
+func (as *AgentServer) Stop() {
+	if as.server != nil {
+		as.server.Stop()
+	}
+}
+
+func (as *AgentServer) GetAssignments(ctx context.Context, req *proto.GetAssignmentsRequest) (*proto.GetAssignmentsResponse, error) {
+	agent, err := as.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []Product
+	if err := as.ps.db.GetDB().WithContext(ctx).Scopes(withCheckConfig).
+		Where(checkedProducts).Where("agent_id = ?", agent.ID).
+		Find(&products).Error; err != nil {
+		log.Printf("Failed to load assignments for agent %d: %v", agent.ID, err)
+		return nil, status.Error(codes.Internal, "failed to load assignments")
+	}
+
+	secure := isTLSPeer(ctx)
+	assigned := make(map[uint]bool, len(products))
+	assignments := make([]*proto.Assignment, 0, len(products))
+	for _, product := range products {
+		var config []byte
+		if product.CheckConfig != nil {
+			if !secure && hasCredentials(product.CheckConfig) {
+				log.Printf("Skipping assignment of product %d to agent %d: its credentials are only sent over TLS", product.ID, agent.ID)
+				continue
+			}
+			if err := revealCredentials(product.CheckConfig); err != nil {
+				log.Printf("Skipping assignment of product %d to agent %d: %v", product.ID, agent.ID, err)
+				continue
+			}
+			if config, err = json.Marshal(product.CheckConfig); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to encode check config for product %d", product.ID)
+			}
+		}
+		assigned[product.ID] = true
+		assignments = append(assignments, &proto.Assignment{
+			ProductId:   uint32(product.ID),
+			HealthApi:   product.HealthAPI,
+			CheckConfig: string(config),
+			Version:     productVersion(product).UnixNano(),
+		})
+	}
+	as.forgetUnassigned(agent.ID, assigned)
+
+	log.Printf("Agent %q (version %s) fetched %d assignments", agent.Name, req.GetAgentVersion(), len(assignments))
+	return &proto.GetAssignmentsResponse{
+		Assignments:    assignments,
+		PollIntervalMs: DefaultAgentPollInterval.Milliseconds(),
+	}, nil
+}
+
+func (as *AgentServer) ReportResults(stream grpc.ClientStreamingServer[proto.CheckReport, proto.ReportResultsResponse]) error {
+	agent, err := as.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	var accepted uint64
+	for {
+		report, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&proto.ReportResultsResponse{Accepted: accepted})
+		}
+		if err != nil {
+			return err
+		}
+		if as.applyReport(agent, report) {
+			accepted++
+		}
+	}
+}
+
+// applyReport records one check result of an agent. Reports for products
+// that are not (or no longer) assigned to the agent are ignored.
+func (as *AgentServer) applyReport(agent *Agent, report *proto.CheckReport) bool {
+	productID := uint(report.GetProductId())
+
+	var product Product
+	if err := as.ps.db.GetDB().Scopes(withCheckConfig).
+		Where("agent_id = ?", agent.ID).
+		First(&product, productID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load product %d reported by agent %d: %v", productID, agent.ID, err)
+		}
+		return false
+	}
+
+	state, err := as.itemFor(agent.ID, product)
+	if err != nil {
+		log.Printf("Failed to load state of product %d: %v", productID, err)
+		return false
+	}
+
+	outcome := &CheckOutcome{
+		Success:    report.GetSuccess(),
+		StatusCode: int(report.GetStatusCode()),
+		Reason:     report.GetReason(),
+		Timings: CheckTimings{
+			DNS:     msDuration(report.GetDnsMs()),
+			Connect: msDuration(report.GetConnectMs()),
+			TLS:     msDuration(report.GetTlsMs()),
+			TTFB:    msDuration(report.GetTtfbMs()),
+			Total:   msDuration(report.GetTotalMs()),
+		},
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	as.ps.recordOutcome(state.item, agentLocationPrefix+agent.Name, time.UnixMilli(report.GetCheckedAtUnixMs()), outcome)
+	return true
+}
+
+// itemFor returns the state of a product, rebuilding the item when the
+// product or its check config changed and restoring open incidents the
+// first time the product is seen.
+func (as *AgentServer) itemFor(agentID uint, product Product) (*agentItem, error) {
+	as.mutex.Lock()
+	state, ok := as.items[product.ID]
+	as.mutex.Unlock()
+
+	if ok {
+		state.mutex.Lock()
+		if !state.item.Version.Equal(productVersion(product)) {
+			item, err := newPingItem(product, time.Now())
+			if err != nil {
+				state.mutex.Unlock()
+				return nil, err
+			}
+			item.inheritState(state.item)
+			state.item = item
+		}
+		state.agentID = agentID
+		state.mutex.Unlock()
+		return state, nil
+	}
+
+	incidents, err := as.ps.loadOpenIncidents()
+	if err != nil {
+		return nil, err
+	}
+	item, err := newPingItem(product, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	restoreState(item, incidents[product.ID])
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	if existing, ok := as.items[product.ID]; ok {
+		return existing, nil
+	}
+	state = &agentItem{agentID: agentID, item: item}
+	as.items[product.ID] = state
+	return state, nil
+}
+
+// forgetUnassigned drops the state of products the agent no longer checks.
+func (as *AgentServer) forgetUnassigned(agentID uint, assigned map[uint]bool) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	for productID, state := range as.items {
+		if state.agentID == agentID && !assigned[productID] {
+			delete(as.items, productID)
+		}
+	}
+}
+
+// authenticate resolves the agent from the "authorization: Bearer <token>"
+// metadata and records that it was seen.
+func (as *AgentServer) authenticate(ctx context.Context) (*Agent, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header")
+	}
+
+	db := as.ps.db.GetDB().WithContext(ctx)
+	var agent Agent
+	if err := db.Where("token_hash = ?", hashAgentToken(token)).First(&agent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.Unauthenticated, "unknown agent token")
+		}
+		return nil, status.Error(codes.Internal, "failed to authenticate agent")
+	}
+
+	if err := db.Model(&agent).Update("last_seen_at", time.Now()).Error; err != nil {
+		log.Printf("Failed to update last seen time of agent %d: %v", agent.ID, err)
+	}
+	return &agent, nil
+}
+
+// isTLSPeer reports whether the agent connected over TLS.
+func isTLSPeer(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	_, ok = p.AuthInfo.(credentials.TLSInfo)
+	return ok
+}
+
+func hasCredentials(config *CheckConfig) bool {
+	for _, header := range config.Headers {
+		if header.Secret {
+			return true
+		}
+	}
+	return config.AuthType != "" || config.TLSClientCert != ""
+}
+
+// revealCredentials decrypts the secrets of config into its write-only
+// fields. Agents have no access to the encryption key and receive them over
+// their authenticated TLS connection instead.
+func revealCredentials(config *CheckConfig) error {
+	for i := range config.Headers {
+		header := &config.Headers[i]
+		if !header.Secret {
+			continue
+		}
+		value, err := decryptSecret(header.EncryptedValue)
+		if err != nil {
+			return fmt.Errorf("header %s: %w", header.Name, err)
+		}
+		header.Value = value
+	}
+	if config.AuthType != "" {
+		secret, err := decryptSecret(config.AuthSecretEncrypted)
+		if err != nil {
+			return fmt.Errorf("auth secret: %w", err)
+		}
+		config.AuthSecret = secret
+	}
+	if config.TLSClientCert != "" {
+		key, err := decryptSecret(config.TLSKeyEncrypted)
+		if err != nil {
+			return fmt.Errorf("client key: %w", err)
+		}
+		config.TLSClientKey = key
+	}
+	return nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	AssertionContains    = "contains"
+	AssertionNotContains = "not_contains"
+	AssertionRegex       = "regex"
+	AssertionJSONPath    = "json_path"
+
+	// MaxAssertionBodyBytes caps how much of a response body is buffered
+	// for assertions.
+	MaxAssertionBodyBytes = 1 << 20
+)
+
+// Assertion is a compiled CheckAssertion.
+type Assertion struct {
+	Type     string
+	Path     []pathSegment
+	RawPath  string
+	Operator string
+	Value    string
+	regex    *regexp.Regexp
+}
+
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+func compileAssertion(a CheckAssertion) (Assertion, error) {
+	compiled := Assertion{
+		Type:     a.Type,
+		RawPath:  a.Path,
+		Operator: a.Operator,
+		Value:    a.Value,
+	}
+
+	switch a.Type {
+	case AssertionContains, AssertionNotContains:
+		if a.Value == "" {
+			return compiled, fmt.Errorf("%s assertion requires a value", a.Type)
+		}
+	case AssertionRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return compiled, fmt.Errorf("invalid regex %q: %w", a.Value, err)
+		}
+		compiled.regex = re
+	case AssertionJSONPath:
+		path, err := parseJSONPath(a.Path)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.Path = path
+		if compiled.Operator == "" {
+			compiled.Operator = "eq"
+		}
+		switch compiled.Operator {
+		case "eq", "ne":
+		case "gt", "gte", "lt", "lte":
+			if _, err := strconv.ParseFloat(a.Value, 64); err != nil {
+				return compiled, fmt.Errorf("operator %s requires a numeric value", compiled.Operator)
+			}
+		default:
+			return compiled, fmt.Errorf("unknown operator %q", compiled.Operator)
+		}
+	default:
+		return compiled, fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+
+	return compiled, nil
+}
+
+// parseJSONPath parses a dotted path such as "$.checks[0].status".
+func parseJSONPath(path string) ([]pathSegment, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return nil, fmt.Errorf("json_path assertion requires a path")
+	}
+
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		var indexes []int
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if !strings.HasPrefix(rest, "[") || end < 0 {
+					return nil, fmt.Errorf("invalid json path %q", path)
+				}
+				idx, err := strconv.Atoi(rest[1:end])
+				if err != nil || idx < 0 {
+					return nil, fmt.Errorf("invalid index in json path %q", path)
+				}
+				indexes = append(indexes, idx)
+				rest = rest[end+1:]
+			}
+		}
+
+		if name == "" && len(indexes) == 0 {
+			return nil, fmt.Errorf("invalid json path %q", path)
+		}
+		if name != "" {
+			segments = append(segments, pathSegment{key: name})
+		}
+		for _, idx := range indexes {
+			segments = append(segments, pathSegment{index: idx, isIdx: true})
+		}
+	}
+
+	return segments, nil
+}
+
+// Evaluate returns an empty string if the assertion holds, otherwise a
+// human readable failure reason. parsed caches the decoded JSON body across
+// assertions of the same response.
+func (a Assertion) Evaluate(body []byte, parsed *interface{}) string {
+	switch a.Type {
+	case AssertionContains:
+		if !strings.Contains(string(body), a.Value) {
+			return fmt.Sprintf("response body does not contain %q", a.Value)
+		}
+	case AssertionNotContains:
+		if strings.Contains(string(body), a.Value) {
+			return fmt.Sprintf("response body contains %q", a.Value)
+		}
+	case AssertionRegex:
+		if !a.regex.Match(body) {
+			return fmt.Sprintf("response body does not match /%s/", a.Value)
+		}
+	case AssertionJSONPath:
+		if *parsed == nil {
+			if err := json.Unmarshal(body, parsed); err != nil {
+				return fmt.Sprintf("response body is not valid JSON: %v", err)
+			}
+		}
+
+		actual, ok := lookupJSONPath(*parsed, a.Path)
+		if !ok {
+			return fmt.Sprintf("json path %s not found", a.RawPath)
+		}
+		if !compareJSONValue(actual, a.Operator, a.Value) {
+			return fmt.Sprintf("json path %s %s %q failed (got %s)", a.RawPath, a.Operator, a.Value, formatJSONValue(actual))
+		}
+	}
+
+	return ""
+}
+
+func lookupJSONPath(doc interface{}, path []pathSegment) (interface{}, bool) {
+	current := doc
+	for _, seg := range path {
+		if seg.isIdx {
+			arr, ok := current.([]interface{})
+			if !ok || seg.index >= len(arr) {
+				return nil, false
+			}
+			current = arr[seg.index]
+			continue
+		}
+
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = obj[seg.key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func compareJSONValue(actual interface{}, operator, expected string) bool {
+	switch operator {
+	case "eq":
+		return formatJSONScalar(actual) == expected
+	case "ne":
+		return formatJSONScalar(actual) != expected
+	}
+
+	number, ok := actual.(float64)
+	if !ok {
+		if s, isString := actual.(string); isString {
+			parsed, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return false
+			}
+			number = parsed
+		} else {
+			return false
+		}
+	}
+	want, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+
+	switch operator {
+	case "gt":
+		return number > want
+	case "gte":
+		return number >= want
+	case "lt":
+		return number < want
+	case "lte":
+		return number <= want
+	}
+	return false
+}
+
+// formatJSONScalar renders a decoded JSON value the way a user would write
+// it in an assertion value: strings unquoted, numbers without exponent.
+func formatJSONScalar(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case nil:
+		return "null"
+	default:
+		return formatJSONValue(v)
+	}
+}
+
+func formatJSONValue(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(encoded) > 200 {
+		return string(encoded[:200]) + "..."
+	}
+	return string(encoded)
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion CheckAssertion
+		wantErr   bool
+	}{
+		{"contains", CheckAssertion{Type: AssertionContains, Value: "ok"}, false},
+		{"contains without value", CheckAssertion{Type: AssertionContains}, true},
+		{"not contains without value", CheckAssertion{Type: AssertionNotContains}, true},
+		{"regex", CheckAssertion{Type: AssertionRegex, Value: `^\{"status":"(ok|up)"`}, false},
+		{"invalid regex", CheckAssertion{Type: AssertionRegex, Value: "(unclosed"}, true},
+		{"json path defaults to eq", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Value: "ok"}, false},
+		{"numeric operator", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gte", Value: "3"}, false},
+		{"numeric operator with text", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gt", Value: "many"}, true},
+		{"unknown operator", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Operator: "like", Value: "ok"}, true},
+		{"missing path", CheckAssertion{Type: AssertionJSONPath, Value: "ok"}, true},
+		{"root path", CheckAssertion{Type: AssertionJSONPath, Path: "$", Value: "ok"}, true},
+		{"unknown type", CheckAssertion{Type: "xpath", Value: "ok"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileAssertion(tt.assertion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileAssertion error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathSegment
+		wantErr bool
+	}{
+		{path: "$.status", want: []pathSegment{{key: "status"}}},
+		{path: "status", want: []pathSegment{{key: "status"}}},
+		{path: " $.data.items ", want: []pathSegment{{key: "data"}, {key: "items"}}},
+		{path: "$.checks[0].status", want: []pathSegment{{key: "checks"}, {index: 0, isIdx: true}, {key: "status"}}},
+		{path: "$.matrix[1][2]", want: []pathSegment{{key: "matrix"}, {index: 1, isIdx: true}, {index: 2, isIdx: true}}},
+		{path: "$[3].id", want: []pathSegment{{index: 3, isIdx: true}, {key: "id"}}},
+		{path: "", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$.a..b", wantErr: true},
+		{path: "$.items[", wantErr: true},
+		{path: "$.items[x]", wantErr: true},
+		{path: "$.items[-1]", wantErr: true},
+		{path: "$.items[0]x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseJSONPath(%q) error = %v, want error %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseJSONPath(%q) = %+v, want %+v", tt.path, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseJSONPath(%q) = %+v, want %+v", tt.path, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAssertionEvaluate(t *testing.T) {
+	const body = `{"status":"ok","count":5,"ratio":0.25,"version":"12","healthy":true,"owner":null,` +
+		`"checks":[{"name":"db","status":"pass"},{"name":"cache","status":"warn"}],"big":1e21}`
+
+	tests := []struct {
+		name       string
+		assertion  CheckAssertion
+		body       string
+		wantReason string // substring of the failure reason, empty if it holds
+	}{
+		{"contains", CheckAssertion{Type: AssertionContains, Value: `"status":"ok"`}, body, ""},
+		{"contains fails", CheckAssertion{Type: AssertionContains, Value: "error"}, body, "does not contain"},
+		{"not contains", CheckAssertion{Type: AssertionNotContains, Value: "error"}, body, ""},
+		{"not contains fails", CheckAssertion{Type: AssertionNotContains, Value: "cache"}, body, "contains"},
+		{"regex", CheckAssertion{Type: AssertionRegex, Value: `"count":\d+`}, body, ""},
+		{"regex fails", CheckAssertion{Type: AssertionRegex, Value: `^OK$`}, body, "does not match"},
+
+		{"eq string", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Value: "ok"}, body, ""},
+		{"eq string fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Value: "down"}, body, `got "ok"`},
+		{"eq number", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "eq", Value: "5"}, body, ""},
+		{"eq fraction", CheckAssertion{Type: AssertionJSONPath, Path: "$.ratio", Value: "0.25"}, body, ""},
+		{"eq large number", CheckAssertion{Type: AssertionJSONPath, Path: "$.big", Value: "1000000000000000000000"}, body, ""},
+		{"eq bool", CheckAssertion{Type: AssertionJSONPath, Path: "$.healthy", Value: "true"}, body, ""},
+		{"eq null", CheckAssertion{Type: AssertionJSONPath, Path: "$.owner", Value: "null"}, body, ""},
+		{"eq array element", CheckAssertion{Type: AssertionJSONPath, Path: "$.checks[1].status", Value: "warn"}, body, ""},
+		{"eq object", CheckAssertion{Type: AssertionJSONPath, Path: "$.checks[0]", Value: `{"name":"db","status":"pass"}`}, body, ""},
+		{"ne", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Operator: "ne", Value: "down"}, body, ""},
+		{"ne fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Operator: "ne", Value: "ok"}, body, "ne"},
+
+		{"gt", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gt", Value: "4"}, body, ""},
+		{"gt equal fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gt", Value: "5"}, body, "gt"},
+		{"gte equal", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gte", Value: "5"}, body, ""},
+		{"gte fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "gte", Value: "5.5"}, body, "gte"},
+		{"lt", CheckAssertion{Type: AssertionJSONPath, Path: "$.ratio", Operator: "lt", Value: "0.5"}, body, ""},
+		{"lt equal fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.ratio", Operator: "lt", Value: "0.25"}, body, "lt"},
+		{"lte equal", CheckAssertion{Type: AssertionJSONPath, Path: "$.ratio", Operator: "lte", Value: "0.25"}, body, ""},
+		{"lte fails", CheckAssertion{Type: AssertionJSONPath, Path: "$.count", Operator: "lte", Value: "-1"}, body, "lte"},
+
+		// Numeric strings compare as numbers, anything else never does
+		{"gt numeric string", CheckAssertion{Type: AssertionJSONPath, Path: "$.version", Operator: "gt", Value: "9"}, body, ""},
+		{"gt text", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Operator: "gt", Value: "1"}, body, "gt"},
+		{"lt bool", CheckAssertion{Type: AssertionJSONPath, Path: "$.healthy", Operator: "lt", Value: "1"}, body, "lt"},
+		{"gte null", CheckAssertion{Type: AssertionJSONPath, Path: "$.owner", Operator: "gte", Value: "0"}, body, "gte"},
+		{"lte array", CheckAssertion{Type: AssertionJSONPath, Path: "$.checks", Operator: "lte", Value: "2"}, body, "lte"},
+
+		{"missing key", CheckAssertion{Type: AssertionJSONPath, Path: "$.uptime", Value: "1"}, body, "not found"},
+		{"index out of range", CheckAssertion{Type: AssertionJSONPath, Path: "$.checks[2].status", Value: "pass"}, body, "not found"},
+		{"index into object", CheckAssertion{Type: AssertionJSONPath, Path: "$.status[0]", Value: "o"}, body, "not found"},
+		{"key into array", CheckAssertion{Type: AssertionJSONPath, Path: "$.checks.status", Value: "pass"}, body, "not found"},
+		{"key into scalar", CheckAssertion{Type: AssertionJSONPath, Path: "$.count.value", Value: "5"}, body, "not found"},
+		{"invalid JSON", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Value: "ok"}, "<html>ok</html>", "not valid JSON"},
+		{"empty body", CheckAssertion{Type: AssertionJSONPath, Path: "$.status", Value: "ok"}, "", "not valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertion, err := compileAssertion(tt.assertion)
+			if err != nil {
+				t.Fatalf("compileAssertion: %v", err)
+			}
+			var parsed interface{}
+			reason := assertion.Evaluate([]byte(tt.body), &parsed)
+			switch {
+			case tt.wantReason == "" && reason != "":
+				t.Fatalf("Evaluate = %q, want it to hold", reason)
+			case tt.wantReason != "" && !strings.Contains(reason, tt.wantReason):
+				t.Fatalf("Evaluate = %q, want a reason containing %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAssertionEvaluateReusesParsedBody(t *testing.T) {
+	first, err := compileAssertion(CheckAssertion{Type: AssertionJSONPath, Path: "$.a", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := compileAssertion(CheckAssertion{Type: AssertionJSONPath, Path: "$.b", Value: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed interface{}
+	if reason := first.Evaluate([]byte(`{"a":1,"b":2}`), &parsed); reason != "" {
+		t.Fatalf("first Evaluate = %q", reason)
+	}
+	// The second assertion must use the cached document, not the body
+	if reason := second.Evaluate(nil, &parsed); reason != "" {
+		t.Fatalf("second Evaluate = %q", reason)
+	}
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CertRecheckInterval bounds how often an unchanged certificate is
+// re-recorded and re-evaluated against the alert thresholds.
+const CertRecheckInterval = time.Hour
+
+var defaultCertAlertDays = []int{30, 14, 7, 1}
+
+type CertificateDetails struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans"`
+	ExpiresAt time.Time `json:"expires_at"`
+	DaysLeft  int       `json:"days_left"`
+}
+
+// CertMonitor records the peer certificate chain seen by TLS-capable checks
+// and emits cert_expiring notifications as the chain crosses each alert
+// threshold.
+type CertMonitor struct {
+	ps        *PingService
+	alertDays []int
+	lastSeen  map[uint]certObservation
+	mutex     sync.Mutex
+}
+
+type certObservation struct {
+	serial     string
+	recordedAt time.Time
+}
+
+func NewCertMonitor(ps *PingService) *CertMonitor {
+	return &CertMonitor{
+		ps:        ps,
+		alertDays: parseCertAlertDays(os.Getenv("CERT_EXPIRY_ALERT_DAYS")),
+		lastSeen:  make(map[uint]certObservation),
+	}
+}
+
+func parseCertAlertDays(spec string) []int {
+	if strings.TrimSpace(spec) == "" {
+		return defaultCertAlertDays
+	}
+
+	var days []int
+	for _, part := range strings.Split(spec, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			log.Printf("Ignoring invalid CERT_EXPIRY_ALERT_DAYS entry %q", part)
+			continue
+		}
+		days = append(days, d)
+	}
+	if len(days) == 0 {
+		return defaultCertAlertDays
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// Observe is called after every check that completed a TLS handshake.
+func (cm *CertMonitor) Observe(productID uint, chain []*x509.Certificate) {
+	if len(chain) == 0 {
+		return
+	}
+
+	leaf := chain[0]
+	serial := leaf.SerialNumber.String()
+	now := time.Now()
+
+	cm.mutex.Lock()
+	last, seen := cm.lastSeen[productID]
+	if seen && last.serial == serial && now.Sub(last.recordedAt) < CertRecheckInterval {
+		cm.mutex.Unlock()
+		return
+	}
+	cm.lastSeen[productID] = certObservation{serial: serial, recordedAt: now}
+	cm.mutex.Unlock()
+
+	if err := cm.record(productID, chain, now); err != nil {
+		log.Printf("Failed to record TLS certificate for product %d: %v", productID, err)
+	}
+}
+
+func (cm *CertMonitor) record(productID uint, chain []*x509.Certificate, now time.Time) error {
+	leaf := chain[0]
+	expiresAt := leaf.NotAfter
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(expiresAt) {
+			expiresAt = cert.NotAfter
+		}
+	}
+
+	db := cm.ps.db.GetDB()
+
+	var record TLSCertificate
+	err := db.Where("product_id = ?", productID).First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	serial := leaf.SerialNumber.String()
+	if record.SerialNumber != serial {
+		// A renewed certificate starts its alert cycle over
+		record.LastAlertDays = 0
+	}
+
+	record.ProductID = productID
+	record.Subject = leaf.Subject.String()
+	record.Issuer = leaf.Issuer.String()
+	record.SANs = strings.Join(certificateSANs(leaf), ",")
+	record.SerialNumber = serial
+	record.NotBefore = leaf.NotBefore
+	record.NotAfter = leaf.NotAfter
+	record.ChainExpiresAt = expiresAt
+	record.CheckedAt = now
+
+	daysLeft := int(math.Ceil(expiresAt.Sub(now).Hours() / 24))
+	threshold := cm.crossedThreshold(daysLeft)
+	shouldAlert := threshold > 0 && (record.LastAlertDays == 0 || threshold < record.LastAlertDays)
+
+	if !shouldAlert {
+		return db.Save(&record).Error
+	}
+
+	// The alert level is only advanced together with a queued notification
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := cm.queueExpiringNotification(tx, productID, &record, daysLeft); err != nil {
+			return fmt.Errorf("failed to queue certificate expiry notification: %w", err)
+		}
+		record.LastAlertDays = threshold
+		return tx.Save(&record).Error
+	})
+	if err != nil {
+		return err
+	}
+	cm.ps.outbox.Notify()
+	return nil
+}
+
+// crossedThreshold returns the smallest configured threshold that daysLeft
+// has reached, or 0 when the certificate is outside every threshold.
+func (cm *CertMonitor) crossedThreshold(daysLeft int) int {
+	crossed := 0
+	for _, d := range cm.alertDays {
+		if daysLeft <= d {
+			crossed = d
+		}
+	}
+	return crossed
+}
+
+func (cm *CertMonitor) queueExpiringNotification(tx *gorm.DB, productID uint, record *TLSCertificate, daysLeft int) error {
+	var product Product
+	if err := tx.Preload("User").First(&product, productID).Error; err != nil {
+		return fmt.Errorf("failed to get product and user info: %w", err)
+	}
+
+	message := fmt.Sprintf("TLS certificate for %s expires in %d days", product.Name, daysLeft)
+	if daysLeft <= 0 {
+		message = fmt.Sprintf("TLS certificate for %s has expired", product.Name)
+	}
+
+	return enqueueNotification(tx, NotificationEvent{
+		ProductID: productID,
+		UserEmail: product.User.Email,
+		Timestamp: time.Now(),
+		EventType: "cert_expiring",
+		Message:   message,
+		Certificate: &CertificateDetails{
+			Subject:   record.Subject,
+			Issuer:    record.Issuer,
+			SANs:      strings.FieldsFunc(record.SANs, func(r rune) bool { return r == ',' }),
+			ExpiresAt: record.ChainExpiresAt,
+			DaysLeft:  daysLeft,
+		},
+	})
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, email)
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	CheckTypeHTTP = "http"
+	CheckTypeTCP  = "tcp"
+	CheckTypeTLS  = "tls"
+	CheckTypeDNS  = "dns"
+	CheckTypeGRPC = "grpc"
+
+	// CheckTypeSynthetic runs the product's steps instead of a single
+	// request to HealthAPI.
+	CheckTypeSynthetic = "synthetic"
+
+	// CheckTypeHeartbeat products are not polled; their jobs report in
+	// through the heartbeat URL instead.
+	CheckTypeHeartbeat = "heartbeat"
+)
+
+// CheckOutcome is the result of a single health check. Reason explains a
+// failure and ends up on the Downtime row and the notification.
+type CheckOutcome struct {
+	Success    bool
+	StatusCode int
+	Reason     string
+
+	// Skipped means the check could not be evaluated for a reason that is
+	// not the product's fault, e.g. a database error. It is neither
+	// recorded nor counted as a failure.
+	Skipped bool
+
+	// PeerCertificates is the chain presented during a TLS handshake, if any.
+	PeerCertificates []*x509.Certificate
+
+	// Components is the per-dependency status from a health document in
+	// the response, if the endpoint returned one.
+	Components []ComponentStatus
+
+	Timings CheckTimings
+}
+
+// CheckTimings is the latency breakdown of a single check. Phases a checker
+// does not go through (e.g. TLS for plain HTTP) stay zero. Total is filled
+// in by the ping service around the whole check.
+type CheckTimings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration
+	Total   time.Duration
+}
+
+// Checker runs one health check against item.HealthAPI. The context carries
+// the product's check timeout.
+type Checker interface {
+	Check(ctx context.Context, item *PingItem) *CheckOutcome
+}
+
+// runCheck runs the checker for the item's check type under its timeout and
+// fills in the total duration.
+func runCheck(ctx context.Context, checkers map[string]Checker, item *PingItem) *CheckOutcome {
+	start := time.Now()
+
+	checker, ok := checkers[item.Config.Type]
+	if !ok {
+		return failedOutcome("unsupported check type %q", item.Config.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, item.Config.Timeout)
+	defer cancel()
+
+	outcome := checker.Check(ctx, item)
+	outcome.Timings.Total = time.Since(start)
+	return outcome
+}
+
+func newCheckers() map[string]Checker {
+	httpChecker := newHTTPChecker()
+	return map[string]Checker{
+		CheckTypeHTTP:      httpChecker,
+		CheckTypeTCP:       &tcpChecker{},
+		CheckTypeTLS:       &tlsChecker{},
+		CheckTypeDNS:       &dnsChecker{},
+		CheckTypeGRPC:      &grpcChecker{},
+		CheckTypeSynthetic: &syntheticChecker{http: httpChecker},
+	}
+}
+
+func isKnownCheckType(checkType string) bool {
+	switch checkType {
+	case CheckTypeHTTP, CheckTypeTCP, CheckTypeTLS, CheckTypeDNS, CheckTypeGRPC, CheckTypeHeartbeat, CheckTypeSynthetic:
+		return true
+	}
+	return false
+}
+
+func failedOutcome(format string, args ...interface{}) *CheckOutcome {
+	return &CheckOutcome{Reason: fmt.Sprintf(format, args...)}
+}
+
+func skippedOutcome(format string, args ...interface{}) *CheckOutcome {
+	return &CheckOutcome{Reason: fmt.Sprintf(format, args...), Skipped: true}
+}
+
+// targetAddress turns a HealthAPI value into a host:port pair. Both plain
+// "host:port" and URLs such as "https://example.com" are accepted; for URLs
+// without a port the scheme's well-known port is used.
+func targetAddress(target, defaultPort string) (string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", err
+		}
+		port := u.Port()
+		if port == "" {
+			switch u.Scheme {
+			case "https":
+				port = "443"
+			case "http":
+				port = "80"
+			default:
+				port = defaultPort
+			}
+		}
+		target = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		if defaultPort == "" {
+			return "", fmt.Errorf("target %q must be host:port", target)
+		}
+		return net.JoinHostPort(target, defaultPort), nil
+	}
+	if host == "" || port == "" {
+		return "", fmt.Errorf("target %q must be host:port", target)
+	}
+	return target, nil
+}
+
+// targetHost strips any scheme, port and path from a HealthAPI value.
+func targetHost(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			return u.Hostname()
+		}
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(target, ".")
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnsChecker resolves the target host and, if DNSExpected is configured,
+// requires it among the answers for DNSRecordType.
+type dnsChecker struct{}
+
+func (c *dnsChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	host := targetHost(item.HealthAPI)
+	if host == "" {
+		return failedOutcome("invalid dns target %q", item.HealthAPI)
+	}
+
+	resolver := net.DefaultResolver
+	if item.Config.DNSResolver != "" {
+		server, err := targetAddress(item.Config.DNSResolver, "53")
+		if err != nil {
+			return failedOutcome("invalid dns resolver: %v", err)
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	recordType := item.Config.DNSRecordType
+	start := time.Now()
+	answers, err := lookupRecords(ctx, resolver, host, recordType)
+	timings := CheckTimings{DNS: time.Since(start)}
+	if err != nil {
+		return dnsFailure(timings, "dns %s lookup for %s failed: %v", recordType, host, err)
+	}
+	if len(answers) == 0 {
+		return dnsFailure(timings, "dns %s lookup for %s returned no records", recordType, host)
+	}
+
+	if expected := item.Config.DNSExpected; expected != "" {
+		want := normalizeDNSAnswer(expected)
+		for _, answer := range answers {
+			if normalizeDNSAnswer(answer) == want {
+				return &CheckOutcome{Success: true, Timings: timings}
+			}
+		}
+		return dnsFailure(timings, "dns %s lookup for %s did not return %q (got %s)",
+			recordType, host, expected, strings.Join(answers, ", "))
+	}
+
+	return &CheckOutcome{Success: true, Timings: timings}
+}
+
+func dnsFailure(timings CheckTimings, format string, args ...interface{}) *CheckOutcome {
+	outcome := failedOutcome(format, args...)
+	outcome.Timings = timings
+	return outcome
+}
+
+func lookupRecords(ctx context.Context, resolver *net.Resolver, host, recordType string) ([]string, error) {
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(ips))
+		for i, ip := range ips {
+			answers[i] = ip.String()
+		}
+		return answers, nil
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return []string{cname}, nil
+	case "MX":
+		records, err := resolver.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(records))
+		for i, mx := range records {
+			answers[i] = mx.Host
+		}
+		return answers, nil
+	case "NS":
+		records, err := resolver.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(records))
+		for i, ns := range records {
+			answers[i] = ns.Host
+		}
+		return answers, nil
+	case "TXT":
+		return resolver.LookupTXT(ctx, host)
+	case "SRV":
+		_, records, err := resolver.LookupSRV(ctx, "", "", host)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(records))
+		for i, srv := range records {
+			answers[i] = net.JoinHostPort(srv.Target, strconv.Itoa(int(srv.Port)))
+		}
+		return answers, nil
+	default:
+		return resolver.LookupHost(ctx, host)
+	}
+}
+
+func normalizeDNSAnswer(answer string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(answer), "."))
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTargetHost(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{" example.com. ", "example.com"},
+		{"example.com:53", "example.com"},
+		{"https://example.com/health", "example.com"},
+		{"http://example.com:8080", "example.com"},
+		{"[::1]:53", "::1"},
+		{"https://[2001:db8::1]/", "2001:db8::1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := targetHost(tt.target); got != tt.want {
+			t.Errorf("targetHost(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestTargetAddress(t *testing.T) {
+	tests := []struct {
+		target      string
+		defaultPort string
+		want        string
+		wantErr     bool
+	}{
+		{target: "db.internal:5432", want: "db.internal:5432"},
+		{target: "1.1.1.1", defaultPort: "53", want: "1.1.1.1:53"},
+		{target: "1.1.1.1:5353", defaultPort: "53", want: "1.1.1.1:5353"},
+		{target: "https://example.com", want: "example.com:443"},
+		{target: "http://example.com/health", want: "example.com:80"},
+		{target: "https://example.com:8443/", want: "example.com:8443"},
+		{target: "grpc://example.com", defaultPort: "50051", want: "example.com:50051"},
+		{target: "[::1]:53", want: "[::1]:53"},
+		{target: "example.com", wantErr: true},
+		{target: ":5432", wantErr: true},
+		{target: "example.com:", wantErr: true},
+		{target: "grpc://example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := targetAddress(tt.target, tt.defaultPort)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("targetAddress(%q, %q) error = %v, want error %v", tt.target, tt.defaultPort, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("targetAddress(%q, %q) = %q, want %q", tt.target, tt.defaultPort, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDNSAnswer(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"mail.example.com.", "mail.example.com"},
+		{" Mail.Example.COM ", "mail.example.com"},
+		{"192.0.2.1", "192.0.2.1"},
+		{"v=spf1 -all", "v=spf1 -all"},
+	}
+	for _, tt := range tests {
+		if got := normalizeDNSAnswer(tt.answer); got != tt.want {
+			t.Errorf("normalizeDNSAnswer(%q) = %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestDNSCheckerLocalhost(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		expected   string
+		wantReason string
+	}{
+		{name: "resolves", target: "localhost"},
+		{name: "expected answer", target: "localhost", expected: "127.0.0.1"},
+		{name: "unexpected answer", target: "localhost", expected: "192.0.2.1", wantReason: `did not return "192.0.2.1"`},
+		{name: "invalid target", target: "", wantReason: "invalid dns target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultCheckSettings()
+			config.Type = CheckTypeDNS
+			config.DNSRecordType = "A"
+			config.DNSExpected = tt.expected
+			item := &PingItem{HealthAPI: tt.target, Config: config}
+
+			outcome := (&dnsChecker{}).Check(context.Background(), item)
+			if tt.wantReason == "" {
+				if !outcome.Success {
+					t.Fatalf("Check failed: %s", outcome.Reason)
+				}
+				return
+			}
+			if outcome.Success || !strings.Contains(outcome.Reason, tt.wantReason) {
+				t.Fatalf("Check = %+v, want a failure containing %q", outcome, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcChecker calls the standard grpc.health.v1.Health/Check method and
+// succeeds when the server reports SERVING. Targets with the grpcs:// scheme
+// use TLS with the product's TLS settings; grpc:// and bare host:port
+// targets connect in plain text. The check's headers are sent as metadata.
+type grpcChecker struct{}
+
+func (c *grpcChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	useTLS := strings.HasPrefix(strings.TrimSpace(item.HealthAPI), "grpcs://")
+	defaultPort := ""
+	if useTLS {
+		defaultPort = "443"
+	}
+	addr, err := targetAddress(item.HealthAPI, defaultPort)
+	if err != nil {
+		return failedOutcome("invalid grpc target: %v", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if useTLS {
+		config := &tls.Config{}
+		if item.Config.TLSConfig != nil {
+			config = item.Config.TLSConfig.Clone()
+		}
+		config.ServerName = targetHost(item.HealthAPI)
+		creds = credentials.NewTLS(config)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return failedOutcome("invalid grpc target: %v", err)
+	}
+	defer conn.Close()
+
+	if len(item.Config.Headers) > 0 {
+		md := metadata.MD{}
+		for name, values := range item.Config.Headers {
+			md.Append(strings.ToLower(name), values...)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	var remote peer.Peer
+	start := time.Now()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx,
+		&grpc_health_v1.HealthCheckRequest{Service: item.Config.GRPCService},
+		grpc.Peer(&remote))
+	timings := CheckTimings{TTFB: time.Since(start)}
+	if err != nil {
+		st := status.Convert(err)
+		outcome := failedOutcome("grpc health check failed with %s: %s", st.Code(), st.Message())
+		outcome.Timings = timings
+		return outcome
+	}
+
+	outcome := &CheckOutcome{Timings: timings}
+	if info, ok := remote.AuthInfo.(credentials.TLSInfo); ok {
+		outcome.PeerCertificates = info.State.PeerCertificates
+	}
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		outcome.Reason = "service reported " + resp.GetStatus().String()
+		return outcome
+	}
+	outcome.Success = true
+	return outcome
+}
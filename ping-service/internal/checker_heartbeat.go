@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// heartbeatChecker succeeds while the product's job keeps calling its
+// heartbeat URL. Nothing is contacted; the check only compares the last
+// heartbeat recorded by the HTTP service with the expected period plus the
+// grace time. Until the first heartbeat arrives the period counts from the
+// last change of the product or its check config.
+type heartbeatChecker struct {
+	db *gorm.DB
+}
+
+func (c *heartbeatChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	last := item.Version
+
+	var heartbeat Heartbeat
+	err := c.db.WithContext(ctx).Where("product_id = ?", item.ProductID).First(&heartbeat).Error
+	switch {
+	case err == nil:
+		if heartbeat.ReceivedAt.After(last) {
+			last = heartbeat.ReceivedAt
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return skippedOutcome("failed to load last heartbeat: %v", err)
+	}
+
+	if time.Since(last) > item.Config.HeartbeatPeriod+item.Config.HeartbeatGrace {
+		return failedOutcome("no heartbeat received since %s, expected every %v",
+			last.UTC().Format(time.RFC3339), item.Config.HeartbeatPeriod)
+	}
+	return &CheckOutcome{Success: true}
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+type httpChecker struct {
+	transport *http.Transport
+	client    *http.Client
+}
+
+func newHTTPChecker() *httpChecker {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Every check dials a fresh connection so the DNS/connect/TLS timings
+	// reflect what a new client would see.
+	transport.DisableKeepAlives = true
+
+	return &httpChecker{
+		transport: transport,
+		client:    &http.Client{Transport: transport},
+	}
+}
+
+// clientFor returns the shared client, or a dedicated one for products with
+// their own TLS settings or redirect policy. Keep-alives are off, so a
+// dedicated transport holds no connections after the check.
+func (c *httpChecker) clientFor(item *PingItem) *http.Client {
+	settings := item.Config
+	if settings.TLSConfig == nil && settings.FollowRedirects && settings.MaxRedirects == DefaultMaxRedirects {
+		return c.client
+	}
+
+	client := &http.Client{
+		Transport: c.transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !settings.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= settings.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", settings.MaxRedirects)
+			}
+			return nil
+		},
+	}
+	if settings.TLSConfig != nil {
+		transport := c.transport.Clone()
+		transport.TLSClientConfig = settings.TLSConfig.Clone()
+		client.Transport = transport
+	}
+	return client
+}
+
+func (c *httpChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	var body io.Reader
+	if item.Config.Body != "" {
+		body = strings.NewReader(item.Config.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, item.Config.Method, item.HealthAPI, body)
+	if err != nil {
+		log.Printf("Failed to create request for %s: %v", item.HealthAPI, err)
+		return failedOutcome("invalid request: %v", err)
+	}
+	if item.Config.Headers != nil {
+		req.Header = item.Config.Headers.Clone()
+		if host := req.Header.Get("Host"); host != "" {
+			req.Host = host
+		}
+	}
+
+	trace, timings := newTimingTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := c.clientFor(item).Do(req)
+	if err != nil {
+		log.Printf("Failed to ping %s: %v", item.HealthAPI, err)
+		outcome := failedOutcome("request failed: %v", err)
+		outcome.Timings = timings()
+		return outcome
+	}
+	defer resp.Body.Close()
+
+	outcome := &CheckOutcome{StatusCode: resp.StatusCode, Timings: timings()}
+	if resp.TLS != nil {
+		outcome.PeerCertificates = resp.TLS.PeerCertificates
+	}
+
+	// Health documents are parsed whatever the status code, since a failing
+	// one usually comes with a 503
+	var data []byte
+	if len(item.Config.Assertions) > 0 || isJSONContentType(resp.Header.Get("Content-Type")) {
+		data, err = io.ReadAll(io.LimitReader(resp.Body, MaxAssertionBodyBytes))
+		if err != nil {
+			outcome.Reason = "failed to read response body: " + err.Error()
+			return outcome
+		}
+		outcome.Components = parseHealthComponents(data)
+	}
+
+	if !item.Config.AcceptsStatus(resp.StatusCode) {
+		log.Printf("Unexpected status code %d from %s", resp.StatusCode, item.HealthAPI)
+		outcome.Reason = "unexpected status code " + resp.Status
+		return outcome
+	}
+
+	if len(item.Config.Assertions) > 0 {
+		var parsed interface{}
+		for _, assertion := range item.Config.Assertions {
+			if reason := assertion.Evaluate(data, &parsed); reason != "" {
+				log.Printf("Assertion failed for %s: %s", item.HealthAPI, reason)
+				outcome.Reason = "assertion failed: " + reason
+				return outcome
+			}
+		}
+	}
+
+	outcome.Success = true
+	return outcome
+}
+
+// newTimingTrace returns a trace that records the request phases and a
+// function returning a copy of the timings so far. The transport may still
+// call the trace after the request failed, e.g. when a dial it started
+// finishes, so the timings must only be read through the copy. TTFB is
+// measured from the start of the request, so it includes DNS, connect and
+// TLS.
+func newTimingTrace() (*httptrace.ClientTrace, func() CheckTimings) {
+	var mutex sync.Mutex
+	var t CheckTimings
+	var start, dnsStart, connectStart, tlsStart time.Time
+	start = time.Now()
+
+	snapshot := func() CheckTimings {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return t
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mutex.Lock()
+			dnsStart = time.Now()
+			mutex.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mutex.Lock()
+			t.DNS = time.Since(dnsStart)
+			mutex.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			mutex.Lock()
+			connectStart = time.Now()
+			mutex.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			mutex.Lock()
+			t.Connect = time.Since(connectStart)
+			mutex.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			mutex.Lock()
+			tlsStart = time.Now()
+			mutex.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mutex.Lock()
+			t.TLS = time.Since(tlsStart)
+			mutex.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mutex.Lock()
+			t.TTFB = time.Since(start)
+			mutex.Unlock()
+		},
+	}, snapshot
+}
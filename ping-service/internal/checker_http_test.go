@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPCheckerTimings(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantReason string
+		wantTLS    bool
+	}{
+		{name: "ok", url: plain.URL + "/"},
+		{name: "tls", url: secure.URL + "/", wantTLS: true},
+		{name: "unexpected status", url: plain.URL + "/missing", wantReason: "unexpected status code"},
+		{name: "timeout", url: plain.URL + "/slow", wantReason: "request failed"},
+		{name: "timeout over tls", url: secure.URL + "/slow", wantReason: "request failed", wantTLS: true},
+	}
+	checker := newHTTPChecker()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultCheckSettings()
+			config.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+			item := &PingItem{HealthAPI: tt.url, Config: config}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			outcome := checker.Check(ctx, item)
+
+			if tt.wantReason == "" && !outcome.Success {
+				t.Fatalf("Check failed: %s", outcome.Reason)
+			}
+			if tt.wantReason != "" && (outcome.Success || !strings.Contains(outcome.Reason, tt.wantReason)) {
+				t.Fatalf("Check = %+v, want a failure containing %q", outcome, tt.wantReason)
+			}
+			if outcome.Timings.Connect <= 0 {
+				t.Errorf("Connect = %v, want it measured", outcome.Timings.Connect)
+			}
+			if (outcome.Timings.TLS > 0) != tt.wantTLS {
+				t.Errorf("TLS = %v, want measured: %v", outcome.Timings.TLS, tt.wantTLS)
+			}
+			if tt.wantReason == "" && outcome.Timings.TTFB < outcome.Timings.Connect {
+				t.Errorf("TTFB = %v, want at least Connect %v", outcome.Timings.TTFB, outcome.Timings.Connect)
+			}
+		})
+	}
+}
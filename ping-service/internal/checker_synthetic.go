@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DefaultStepTimeout = 10 * time.Second
+
+// stepVariable matches a {{name}} reference to an extracted value.
+var stepVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// SyntheticStep is a compiled CheckStep.
+type SyntheticStep struct {
+	Name         string
+	Method       string
+	URL          string
+	Headers      map[string]string
+	Body         string
+	StatusRanges []StatusRange
+	Timeout      time.Duration
+	Extract      map[string][]pathSegment
+	Assertions   []Assertion
+}
+
+func compileStep(step CheckStep) (SyntheticStep, error) {
+	compiled := SyntheticStep{
+		Name:    step.Name,
+		Method:  strings.ToUpper(step.Method),
+		URL:     step.URL,
+		Headers: step.Headers,
+		Body:    step.Body,
+		Timeout: DefaultStepTimeout,
+		Extract: make(map[string][]pathSegment, len(step.Extract)),
+	}
+	if compiled.Method == "" {
+		compiled.Method = http.MethodGet
+	}
+	if step.TimeoutMs > 0 {
+		compiled.Timeout = time.Duration(step.TimeoutMs) * time.Millisecond
+	}
+
+	statusCodes := step.ExpectedStatusCodes
+	if strings.TrimSpace(statusCodes) == "" {
+		statusCodes = "200"
+	}
+	ranges, err := ParseStatusCodes(statusCodes)
+	if err != nil {
+		return compiled, err
+	}
+	compiled.StatusRanges = ranges
+
+	for name, path := range step.Extract {
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			return compiled, fmt.Errorf("extract %s: %w", name, err)
+		}
+		compiled.Extract[name] = segments
+	}
+	for i, a := range step.Assertions {
+		assertion, err := compileAssertion(CheckAssertion{Type: a.Type, Path: a.Path, Operator: a.Operator, Value: a.Value})
+		if err != nil {
+			return compiled, fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+		compiled.Assertions = append(compiled.Assertions, assertion)
+	}
+	return compiled, nil
+}
+
+// syntheticChecker runs the steps of a synthetic check in order with a
+// shared cookie jar, so a login step can authenticate the ones after it.
+// The check's headers, auth and TLS settings apply to every step. The first
+// failing step ends the run and is named in the outcome's reason.
+type syntheticChecker struct {
+	http *httpChecker
+}
+
+func (c *syntheticChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	if len(item.Config.Steps) == 0 {
+		return failedOutcome("synthetic check has no steps")
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return failedOutcome("failed to create cookie jar: %v", err)
+	}
+	client := *c.http.clientFor(item)
+	client.Jar = jar
+
+	variables := make(map[string]string)
+	var outcome *CheckOutcome
+	for i, step := range item.Config.Steps {
+		var reason string
+		outcome, reason = c.runStep(ctx, &client, item, step, variables)
+		if reason != "" {
+			outcome.Success = false
+			outcome.Reason = fmt.Sprintf("step %d %q failed: %s", i+1, step.Name, reason)
+			return outcome
+		}
+	}
+
+	outcome.Success = true
+	return outcome
+}
+
+// runStep performs one step and stores its extracted values in variables.
+// It returns a failure reason, or an empty string if the step passed.
+func (c *syntheticChecker) runStep(ctx context.Context, client *http.Client, item *PingItem, step SyntheticStep, variables map[string]string) (*CheckOutcome, string) {
+	outcome := &CheckOutcome{}
+
+	url, err := expandVariables(step.URL, variables)
+	if err != nil {
+		return outcome, err.Error()
+	}
+	body, err := expandVariables(step.Body, variables)
+	if err != nil {
+		return outcome, err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, step.Method, url, reader)
+	if err != nil {
+		return outcome, fmt.Sprintf("invalid request: %v", err)
+	}
+	if item.Config.Headers != nil {
+		req.Header = item.Config.Headers.Clone()
+	}
+	for name, value := range step.Headers {
+		expanded, err := expandVariables(value, variables)
+		if err != nil {
+			return outcome, err.Error()
+		}
+		req.Header.Set(name, expanded)
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		if json.Valid([]byte(body)) {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	trace, timings := newTimingTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	defer func() { outcome.Timings = timings() }()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return outcome, fmt.Sprintf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	outcome.StatusCode = resp.StatusCode
+	if resp.TLS != nil {
+		outcome.PeerCertificates = resp.TLS.PeerCertificates
+	}
+	if !acceptsStatus(step.StatusRanges, resp.StatusCode) {
+		return outcome, "unexpected status code " + resp.Status
+	}
+	if len(step.Assertions) == 0 && len(step.Extract) == 0 {
+		return outcome, ""
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxAssertionBodyBytes))
+	if err != nil {
+		return outcome, "failed to read response body: " + err.Error()
+	}
+
+	var parsed interface{}
+	for _, assertion := range step.Assertions {
+		if reason := assertion.Evaluate(data, &parsed); reason != "" {
+			return outcome, "assertion failed: " + reason
+		}
+	}
+
+	if len(step.Extract) > 0 {
+		if parsed == nil {
+			if err := json.Unmarshal(data, &parsed); err != nil {
+				return outcome, fmt.Sprintf("response body is not valid JSON: %v", err)
+			}
+		}
+		for name, path := range step.Extract {
+			value, ok := lookupJSONPath(parsed, path)
+			if !ok {
+				return outcome, fmt.Sprintf("value for %s not found in response", name)
+			}
+			variables[name] = formatJSONScalar(value)
+		}
+	}
+	return outcome, ""
+}
+
+// expandVariables replaces {{name}} references with extracted values.
+func expandVariables(s string, variables map[string]string) (string, error) {
+	var missing string
+	expanded := stepVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name := stepVariable.FindStringSubmatch(match)[1]
+		value, ok := variables[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("variable %s is not defined by an earlier step", missing)
+	}
+	return expanded, nil
+}
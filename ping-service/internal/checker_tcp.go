@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// tcpChecker succeeds when a TCP connection to the target can be opened.
+type tcpChecker struct{}
+
+func (c *tcpChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	addr, err := targetAddress(item.HealthAPI, "")
+	if err != nil {
+		return failedOutcome("invalid tcp target: %v", err)
+	}
+
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return failedOutcome("tcp connect to %s failed: %v", addr, err)
+	}
+	conn.Close()
+
+	return &CheckOutcome{Success: true, Timings: CheckTimings{Connect: time.Since(start)}}
+}
+
+// tlsChecker succeeds when a TLS handshake with a verified certificate
+// chain completes, using the product's TLS settings if it has any. Port 443
+// is assumed when the target has none.
+type tlsChecker struct{}
+
+func (c *tlsChecker) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	addr, err := targetAddress(item.HealthAPI, "443")
+	if err != nil {
+		return failedOutcome("invalid tls target: %v", err)
+	}
+
+	// Dial and handshake separately so the two phases can be timed.
+	var dialer net.Dialer
+	var timings CheckTimings
+	start := time.Now()
+	rawConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return failedOutcome("tcp connect to %s failed: %v", addr, err)
+	}
+	timings.Connect = time.Since(start)
+
+	config := &tls.Config{}
+	if item.Config.TLSConfig != nil {
+		config = item.Config.TLSConfig.Clone()
+	}
+	config.ServerName = targetHost(item.HealthAPI)
+	conn := tls.Client(rawConn, config)
+	defer conn.Close()
+
+	start = time.Now()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		outcome := failedOutcome("tls handshake with %s failed: %v", addr, err)
+		outcome.Timings = timings
+		return outcome
+	}
+	timings.TLS = time.Since(start)
+
+	state := conn.ConnectionState()
+	return &CheckOutcome{Success: true, PeerCertificates: state.PeerCertificates, Timings: timings}
+}
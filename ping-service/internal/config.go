@@ -0,0 +1,367 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCheckTimeout = 30 * time.Second
+	MinCheckInterval    = time.Second
+	MinCheckTimeout     = 100 * time.Millisecond
+
+	DefaultDegradedAfter = 3
+	DefaultFlapThreshold = 5
+	DefaultFlapWindow    = 10 * time.Minute
+
+	DefaultHeartbeatPeriod = 24 * time.Hour
+	DefaultHeartbeatGrace  = 5 * time.Minute
+
+	DefaultMaxRedirects = 10
+
+	MaxScheduleJitter = 5 * time.Second
+)
+
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// CheckSettings is the resolved form of a product's CheckConfig that a
+// PingItem carries between checks.
+type CheckSettings struct {
+	Type         string
+	Interval     time.Duration
+	Timeout      time.Duration
+	StatusRanges []StatusRange
+	MaxRetries   int
+	BackoffBase  time.Duration
+	Assertions   []Assertion
+
+	DNSRecordType string
+	DNSExpected   string
+	DNSResolver   string
+	GRPCService   string
+
+	// DegradedThreshold is the latency above which a successful check
+	// counts as slow; zero disables degraded detection.
+	DegradedThreshold time.Duration
+	DegradedAfter     int
+
+	// Quorum is the number of probe locations that must report the
+	// product down before the coordinator opens a downtime.
+	Quorum int
+
+	// FlapThreshold up/down changes within FlapWindow mark the product
+	// as flapping.
+	FlapThreshold int
+	FlapWindow    time.Duration
+
+	// HeartbeatPeriod is how often a heartbeat product reports in, with
+	// HeartbeatGrace of slack before it counts as down.
+	HeartbeatPeriod time.Duration
+	HeartbeatGrace  time.Duration
+
+	// Method, Body and Headers make up the request of http checks. The
+	// headers include the decrypted credentials.
+	Method  string
+	Body    string
+	Headers http.Header
+
+	// TLSConfig replaces the default TLS settings of http, tls and grpcs
+	// checks; nil keeps them.
+	TLSConfig *tls.Config
+
+	// FollowRedirects lets http checks follow up to MaxRedirects
+	// redirects. Otherwise the redirect response itself is evaluated.
+	FollowRedirects bool
+	MaxRedirects    int
+
+	// Steps are the requests of a synthetic check, in order.
+	Steps []SyntheticStep
+}
+
+func DefaultCheckSettings() CheckSettings {
+	return CheckSettings{
+		Type:         CheckTypeHTTP,
+		Interval:     DefaultPingInterval,
+		Timeout:      DefaultCheckTimeout,
+		StatusRanges: []StatusRange{{Min: 200, Max: 200}},
+		MaxRetries:   MaxRetries,
+		BackoffBase:  BaseBackoffDelay,
+
+		DegradedAfter: DefaultDegradedAfter,
+		Quorum:        1,
+		FlapThreshold: DefaultFlapThreshold,
+		FlapWindow:    DefaultFlapWindow,
+
+		HeartbeatPeriod: DefaultHeartbeatPeriod,
+		HeartbeatGrace:  DefaultHeartbeatGrace,
+
+		Method: http.MethodGet,
+
+		FollowRedirects: true,
+		MaxRedirects:    DefaultMaxRedirects,
+	}
+}
+
+// NewCheckSettings applies cfg on top of the defaults. A nil cfg or zero
+// fields keep the default values; an invalid cfg is an error, never a
+// fallback to the defaults.
+func NewCheckSettings(cfg *CheckConfig) (CheckSettings, error) {
+	settings := DefaultCheckSettings()
+	if cfg == nil {
+		return settings, nil
+	}
+
+	if cfg.CheckType != "" {
+		if !isKnownCheckType(cfg.CheckType) {
+			return CheckSettings{}, fmt.Errorf("unknown check type %q", cfg.CheckType)
+		}
+		settings.Type = cfg.CheckType
+	}
+	settings.DNSRecordType = strings.ToUpper(strings.TrimSpace(cfg.DNSRecordType))
+	settings.DNSExpected = strings.TrimSpace(cfg.DNSExpected)
+	settings.DNSResolver = strings.TrimSpace(cfg.DNSResolver)
+	settings.GRPCService = strings.TrimSpace(cfg.GRPCService)
+
+	if cfg.IntervalMs > 0 {
+		settings.Interval = time.Duration(cfg.IntervalMs) * time.Millisecond
+		if settings.Interval < MinCheckInterval {
+			settings.Interval = MinCheckInterval
+		}
+	}
+	if cfg.TimeoutMs > 0 {
+		settings.Timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+		if settings.Timeout < MinCheckTimeout {
+			settings.Timeout = MinCheckTimeout
+		}
+	}
+	if cfg.MaxRetries > 0 {
+		settings.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.BackoffBaseMs > 0 {
+		settings.BackoffBase = time.Duration(cfg.BackoffBaseMs) * time.Millisecond
+	}
+	if cfg.DegradedThresholdMs > 0 {
+		settings.DegradedThreshold = time.Duration(cfg.DegradedThresholdMs) * time.Millisecond
+	}
+	if cfg.DegradedAfter > 0 {
+		settings.DegradedAfter = cfg.DegradedAfter
+	}
+	if cfg.Quorum > 0 {
+		settings.Quorum = cfg.Quorum
+	}
+	if cfg.FlapThreshold > 0 {
+		settings.FlapThreshold = cfg.FlapThreshold
+	}
+	if cfg.FlapWindowMs > 0 {
+		settings.FlapWindow = time.Duration(cfg.FlapWindowMs) * time.Millisecond
+	}
+	if cfg.HeartbeatPeriodMs > 0 {
+		settings.HeartbeatPeriod = time.Duration(cfg.HeartbeatPeriodMs) * time.Millisecond
+	}
+	if cfg.HeartbeatGraceMs > 0 {
+		settings.HeartbeatGrace = time.Duration(cfg.HeartbeatGraceMs) * time.Millisecond
+	}
+	if err := resolveRequest(cfg, &settings); err != nil {
+		return CheckSettings{}, err
+	}
+	if err := resolveTLS(cfg, &settings); err != nil {
+		return CheckSettings{}, err
+	}
+	if cfg.RedirectPolicy == "none" {
+		settings.FollowRedirects = false
+	}
+	if cfg.MaxRedirects > 0 {
+		settings.MaxRedirects = cfg.MaxRedirects
+	}
+	if strings.TrimSpace(cfg.ExpectedStatusCodes) != "" {
+		ranges, err := ParseStatusCodes(cfg.ExpectedStatusCodes)
+		if err != nil {
+			return CheckSettings{}, err
+		}
+		settings.StatusRanges = ranges
+	}
+	for _, step := range cfg.Steps {
+		compiled, err := compileStep(step)
+		if err != nil {
+			return CheckSettings{}, fmt.Errorf("step %d: %w", step.Position, err)
+		}
+		settings.Steps = append(settings.Steps, compiled)
+	}
+	for _, a := range cfg.Assertions {
+		compiled, err := compileAssertion(a)
+		if err != nil {
+			return CheckSettings{}, fmt.Errorf("assertion %d: %w", a.ID, err)
+		}
+		settings.Assertions = append(settings.Assertions, compiled)
+	}
+
+	return settings, nil
+}
+
+// resolveRequest builds the request options of an http check. Secrets are
+// decrypted unless they arrived in plain text, as they do on agents.
+func resolveRequest(cfg *CheckConfig, settings *CheckSettings) error {
+	if cfg.Method != "" {
+		settings.Method = strings.ToUpper(cfg.Method)
+	}
+	settings.Body = cfg.RequestBody
+
+	headers := make(http.Header)
+	for _, header := range cfg.Headers {
+		value := header.Value
+		if header.Secret && value == "" {
+			decrypted, err := decryptSecret(header.EncryptedValue)
+			if err != nil {
+				return fmt.Errorf("header %s: %w", header.Name, err)
+			}
+			value = decrypted
+		}
+		headers.Add(header.Name, value)
+	}
+
+	if cfg.AuthType != "" {
+		secret := cfg.AuthSecret
+		if secret == "" {
+			decrypted, err := decryptSecret(cfg.AuthSecretEncrypted)
+			if err != nil {
+				return fmt.Errorf("auth secret: %w", err)
+			}
+			secret = decrypted
+		}
+		switch cfg.AuthType {
+		case "basic":
+			credentials := base64.StdEncoding.EncodeToString([]byte(cfg.AuthUsername + ":" + secret))
+			headers.Set("Authorization", "Basic "+credentials)
+		case "bearer":
+			headers.Set("Authorization", "Bearer "+secret)
+		default:
+			return fmt.Errorf("unknown auth type %q", cfg.AuthType)
+		}
+	}
+
+	if settings.Body != "" && headers.Get("Content-Type") == "" {
+		if json.Valid([]byte(settings.Body)) {
+			headers.Set("Content-Type", "application/json")
+		} else {
+			headers.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	if len(headers) > 0 {
+		settings.Headers = headers
+	}
+	return nil
+}
+
+// resolveTLS builds the TLS settings of a check from its CA bundle, client
+// certificate and skip-verify flag.
+func resolveTLS(cfg *CheckConfig, settings *CheckSettings) error {
+	if cfg.TLSClientCert == "" && cfg.TLSCABundle == "" && !cfg.TLSSkipVerify {
+		return nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: cfg.TLSSkipVerify}
+	if cfg.TLSCABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.TLSCABundle)) {
+			return errors.New("ca bundle contains no certificates")
+		}
+		config.RootCAs = pool
+	}
+	if cfg.TLSClientCert != "" {
+		key := cfg.TLSClientKey
+		if key == "" {
+			decrypted, err := decryptSecret(cfg.TLSKeyEncrypted)
+			if err != nil {
+				return fmt.Errorf("client key: %w", err)
+			}
+			key = decrypted
+		}
+		certificate, err := tls.X509KeyPair([]byte(cfg.TLSClientCert), []byte(key))
+		if err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	settings.TLSConfig = config
+	return nil
+}
+
+// ParseStatusCodes parses a comma separated list of status codes and
+// inclusive ranges, e.g. "200-299,301".
+func ParseStatusCodes(spec string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		max := min
+		if len(bounds) == 2 {
+			max, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code range %q", part)
+			}
+		}
+
+		if min < 100 || max > 599 || min > max {
+			return nil, fmt.Errorf("invalid status code range %q", part)
+		}
+		ranges = append(ranges, StatusRange{Min: min, Max: max})
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no status codes in %q", spec)
+	}
+	return ranges, nil
+}
+
+func (s CheckSettings) AcceptsStatus(code int) bool {
+	return acceptsStatus(s.StatusRanges, code)
+}
+
+func acceptsStatus(ranges []StatusRange, code int) bool {
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleJitter is a random delay of up to a tenth of the interval, at most
+// MaxScheduleJitter, added to every scheduled check. Products that were added
+// or checked together drift apart instead of firing in the same instant.
+func (s CheckSettings) ScheduleJitter() time.Duration {
+	limit := min(s.Interval/10, MaxScheduleJitter)
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// BackoffDelay returns the delay before the given retry attempt (1-based).
+func (s CheckSettings) BackoffDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return s.BackoffBase * time.Duration(1<<(attempt-1))
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []StatusRange
+		wantErr bool
+	}{
+		{spec: "200", want: []StatusRange{{200, 200}}},
+		{spec: "200-299", want: []StatusRange{{200, 299}}},
+		{spec: "200-299,301", want: []StatusRange{{200, 299}, {301, 301}}},
+		{spec: " 200 - 204 , 301 ,", want: []StatusRange{{200, 204}, {301, 301}}},
+		{spec: "100-599", want: []StatusRange{{100, 599}}},
+		{spec: "", wantErr: true},
+		{spec: " , ", wantErr: true},
+		{spec: "ok", wantErr: true},
+		{spec: "200-", wantErr: true},
+		{spec: "-200", wantErr: true},
+		{spec: "299-200", wantErr: true},
+		{spec: "99", wantErr: true},
+		{spec: "600", wantErr: true},
+		{spec: "200-600", wantErr: true},
+		{spec: "2xx", wantErr: true},
+		{spec: "200-250-299", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStatusCodes(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStatusCodes(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseStatusCodes(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseStatusCodes(%q) = %v, want %v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAcceptsStatus(t *testing.T) {
+	settings := DefaultCheckSettings()
+	if !settings.AcceptsStatus(200) || settings.AcceptsStatus(204) {
+		t.Fatalf("default settings must accept exactly 200")
+	}
+
+	ranges, err := ParseStatusCodes("200-299,301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.StatusRanges = ranges
+	tests := map[int]bool{199: false, 200: true, 250: true, 299: true, 300: false, 301: true, 302: false, 500: false}
+	for code, want := range tests {
+		if got := settings.AcceptsStatus(code); got != want {
+			t.Errorf("AcceptsStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestResolveTLS(t *testing.T) {
+	certPEM, keyPEM := newTestCertificate(t)
+	_, otherKeyPEM := newTestCertificate(t)
+
+	tests := []struct {
+		name       string
+		cfg        CheckConfig
+		wantConfig bool
+		wantErr    bool
+	}{
+		{name: "nothing configured"},
+		{name: "skip verify", cfg: CheckConfig{TLSSkipVerify: true}, wantConfig: true},
+		{name: "ca bundle", cfg: CheckConfig{TLSCABundle: certPEM}, wantConfig: true},
+		{name: "ca bundle without certificates", cfg: CheckConfig{TLSCABundle: "not a certificate"}, wantErr: true},
+		{name: "client certificate", cfg: CheckConfig{TLSClientCert: certPEM, TLSClientKey: keyPEM}, wantConfig: true},
+		{name: "client certificate with the wrong key", cfg: CheckConfig{TLSClientCert: certPEM, TLSClientKey: otherKeyPEM}, wantErr: true},
+		{name: "client certificate with an invalid key", cfg: CheckConfig{TLSClientCert: certPEM, TLSClientKey: "not a key"}, wantErr: true},
+		{name: "client certificate with an undecryptable key", cfg: CheckConfig{TLSClientCert: certPEM, TLSKeyEncrypted: "garbage"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultCheckSettings()
+			err := resolveTLS(&tt.cfg, &settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveTLS error = %v, want error %v", err, tt.wantErr)
+			}
+			if (settings.TLSConfig != nil) != tt.wantConfig {
+				t.Fatalf("TLSConfig = %v, want one: %v", settings.TLSConfig, tt.wantConfig)
+			}
+			if tt.wantConfig {
+				if settings.TLSConfig.InsecureSkipVerify != tt.cfg.TLSSkipVerify {
+					t.Errorf("InsecureSkipVerify = %v", settings.TLSConfig.InsecureSkipVerify)
+				}
+				if (settings.TLSConfig.RootCAs != nil) != (tt.cfg.TLSCABundle != "") {
+					t.Errorf("RootCAs = %v", settings.TLSConfig.RootCAs)
+				}
+				if (len(settings.TLSConfig.Certificates) == 1) != (tt.cfg.TLSClientCert != "") {
+					t.Errorf("Certificates = %d", len(settings.TLSConfig.Certificates))
+				}
+			}
+		})
+	}
+}
+
+func newTestCertificate(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CoordinationNone  = "none"
+	CoordinationLease = "lease"
+
+	DefaultLeaseTTL = 30 * time.Second
+)
+
+// LeaseCoordinator splits the monitored products between ping-service
+// replicas. Every replica heartbeats into ping_instances and picks the
+// products it should own by rendezvous hashing over the live replicas. A
+// product is only checked while the replica holds an unexpired row in
+// product_leases, and leases are never taken over before they expire, so
+// two replicas cannot own the same product at once.
+type LeaseCoordinator struct {
+	db            *gorm.DB
+	instanceID    string
+	ttl           time.Duration
+	renewInterval time.Duration
+	onChange      func()
+
+	mutex      sync.RWMutex
+	owned      map[uint]bool
+	validUntil time.Time
+}
+
+func NewLeaseCoordinator(db *gorm.DB, instanceID string, ttl time.Duration, onChange func()) *LeaseCoordinator {
+	return &LeaseCoordinator{
+		db:            db,
+		instanceID:    instanceID,
+		ttl:           ttl,
+		renewInterval: ttl / 3,
+		onChange:      onChange,
+		owned:         make(map[uint]bool),
+	}
+}
+
+// newInstanceID returns PING_INSTANCE_ID or a hostname based identifier
+// that is unique per process.
+func newInstanceID() string {
+	if id := os.Getenv("PING_INSTANCE_ID"); id != "" {
+		return id
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "ping-service"
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Owns reports whether this replica may check the product right now. Once
+// the last successful renewal is older than the lease TTL nothing is owned,
+// since the leases may already have been taken over.
+func (lc *LeaseCoordinator) Owns(productID uint) bool {
+	lc.mutex.RLock()
+	defer lc.mutex.RUnlock()
+	if time.Now().After(lc.validUntil) {
+		return false
+	}
+	return lc.owned[productID]
+}
+
+func (lc *LeaseCoordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(lc.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := lc.Sync(ctx); err != nil {
+				log.Printf("Failed to renew product leases: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sync heartbeats, releases products that now belong to another replica
+// and acquires or renews the rest.
+func (lc *LeaseCoordinator) Sync(ctx context.Context) error {
+	start := time.Now()
+	db := lc.db.WithContext(ctx)
+	ttl := fmt.Sprintf("%d milliseconds", lc.ttl.Milliseconds())
+
+	if err := db.Exec(`INSERT INTO ping_instances (id, started_at, heartbeat_at) VALUES (?, now(), now())
+		ON CONFLICT (id) DO UPDATE SET heartbeat_at = now()`, lc.instanceID).Error; err != nil {
+		return fmt.Errorf("heartbeat: %w", err)
+	}
+
+	var instances []string
+	if err := db.Model(&PingInstance{}).
+		Where("heartbeat_at > now() - ?::interval", ttl).
+		Pluck("id", &instances).Error; err != nil {
+		return fmt.Errorf("list instances: %w", err)
+	}
+	if !slices.Contains(instances, lc.instanceID) {
+		instances = append(instances, lc.instanceID)
+	}
+
+	var productIDs []uint
+	if err := db.Model(&Product{}).Where(scheduledProducts).Pluck("id", &productIDs).Error; err != nil {
+		return fmt.Errorf("list products: %w", err)
+	}
+
+	desired := make(map[uint]bool)
+	var desiredIDs []uint
+	for _, productID := range productIDs {
+		if rendezvousOwner(productID, instances) == lc.instanceID {
+			desired[productID] = true
+			desiredIDs = append(desiredIDs, productID)
+		}
+	}
+
+	// Stop checking released products before the lease rows go away
+	released := false
+	lc.mutex.Lock()
+	for productID := range lc.owned {
+		if !desired[productID] {
+			delete(lc.owned, productID)
+			released = true
+		}
+	}
+	lc.mutex.Unlock()
+
+	release := db.Where("instance_id = ?", lc.instanceID)
+	if len(desiredIDs) > 0 {
+		release = release.Where("product_id NOT IN ?", desiredIDs)
+	}
+	if err := release.Delete(&ProductLease{}).Error; err != nil {
+		return fmt.Errorf("release leases: %w", err)
+	}
+
+	if len(desiredIDs) > 0 {
+		if err := db.Exec(`INSERT INTO product_leases (product_id, instance_id, expires_at)
+			SELECT unnest(ARRAY[?]::bigint[]), ?, now() + ?::interval
+			ON CONFLICT (product_id) DO UPDATE SET instance_id = EXCLUDED.instance_id, expires_at = EXCLUDED.expires_at
+			WHERE product_leases.instance_id = EXCLUDED.instance_id OR product_leases.expires_at < now()`,
+			desiredIDs, lc.instanceID, ttl).Error; err != nil {
+			return fmt.Errorf("acquire leases: %w", err)
+		}
+	}
+
+	var ownedIDs []uint
+	if err := db.Model(&ProductLease{}).
+		Where("instance_id = ? AND expires_at > now()", lc.instanceID).
+		Pluck("product_id", &ownedIDs).Error; err != nil {
+		return fmt.Errorf("list leases: %w", err)
+	}
+
+	if err := db.Where("heartbeat_at < now() - ?::interval", fmt.Sprintf("%d milliseconds", 10*lc.ttl.Milliseconds())).
+		Delete(&PingInstance{}).Error; err != nil {
+		log.Printf("Failed to prune stale ping instances: %v", err)
+	}
+
+	owned := make(map[uint]bool, len(ownedIDs))
+	for _, productID := range ownedIDs {
+		owned[productID] = true
+	}
+
+	lc.mutex.Lock()
+	lapsed := time.Now().After(lc.validUntil)
+	changed := lapsed || released || len(owned) != len(lc.owned)
+	for productID := range owned {
+		if !lc.owned[productID] {
+			changed = true
+		}
+	}
+	lc.owned = owned
+	lc.validUntil = start.Add(lc.ttl)
+	lc.mutex.Unlock()
+
+	if changed {
+		log.Printf("Instance %s owns %d of %d products across %d instances",
+			lc.instanceID, len(owned), len(productIDs), len(instances))
+		if lc.onChange != nil {
+			lc.onChange()
+		}
+	}
+	return nil
+}
+
+// Release gives up every lease held by this replica so the others can take
+// over without waiting for expiry.
+func (lc *LeaseCoordinator) Release() {
+	lc.mutex.Lock()
+	lc.owned = make(map[uint]bool)
+	lc.mutex.Unlock()
+
+	if err := lc.db.Where("instance_id = ?", lc.instanceID).Delete(&ProductLease{}).Error; err != nil {
+		log.Printf("Failed to release product leases: %v", err)
+	}
+	if err := lc.db.Where("id = ?", lc.instanceID).Delete(&PingInstance{}).Error; err != nil {
+		log.Printf("Failed to deregister ping instance: %v", err)
+	}
+}
+
+// rendezvousOwner picks the instance with the highest hash for the product,
+// so adding or removing an instance only moves that instance's share.
+func rendezvousOwner(productID uint, instances []string) string {
+	var owner string
+	var best uint64
+	for _, instance := range instances {
+		h := fnv.New64a()
+		h.Write([]byte(instance))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatUint(uint64(productID), 10)))
+		if score := h.Sum64(); owner == "" || score > best {
+			owner = instance
+			best = score
+		}
+	}
+	return owner
+}
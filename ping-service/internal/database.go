@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -96,5 +97,55 @@ func (s *service) Close() error {
 }
 
 func (s *service) migrate() error {
-	return s.db.AutoMigrate(&User{}, &Product{}, &Log{}, &Downtime{}, &ProductQuickFix{})
+	if err := s.db.AutoMigrate(&User{}, &Agent{}, &Product{}, &Log{}, &Downtime{}, &ProductQuickFix{}, &CheckConfig{}, &CheckAssertion{}, &CheckHeader{}, &CheckStep{}, &TLSCertificate{}, &CheckResult{}, &PingInstance{}, &ProductLease{}, &LocationStatus{}, &DowntimeLocationResult{}, &MaintenanceWindow{}, &NotificationOutbox{}, &Heartbeat{}, &ProductDependency{}); err != nil {
+		return err
+	}
+	return s.setupCheckResultsHypertable()
+}
+
+// setupCheckResultsHypertable turns check_results into a hypertable and
+// attaches compression and retention policies. Every step is idempotent.
+func (s *service) setupCheckResultsHypertable() error {
+	if err := s.db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb CASCADE;").Error; err != nil {
+		return fmt.Errorf("failed to enable TimescaleDB: %w", err)
+	}
+
+	if err := s.db.Exec("SELECT create_hypertable('check_results', 'timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);").Error; err != nil {
+		return fmt.Errorf("failed to create check_results hypertable: %w", err)
+	}
+
+	var compressed bool
+	if err := s.db.Raw("SELECT compression_enabled FROM timescaledb_information.hypertables WHERE hypertable_name = 'check_results'").Scan(&compressed).Error; err != nil {
+		return fmt.Errorf("failed to inspect check_results hypertable: %w", err)
+	}
+	if !compressed {
+		if err := s.db.Exec("ALTER TABLE check_results SET (timescaledb.compress, timescaledb.compress_segmentby = 'product_id', timescaledb.compress_orderby = 'timestamp DESC');").Error; err != nil {
+			return fmt.Errorf("failed to enable check_results compression: %w", err)
+		}
+	}
+
+	compressAfter := envDays("CHECK_RESULTS_COMPRESS_AFTER_DAYS", 7)
+	retention := envDays("CHECK_RESULTS_RETENTION_DAYS", 90)
+
+	if err := s.db.Exec(fmt.Sprintf("SELECT add_compression_policy('check_results', INTERVAL '%d days', if_not_exists => TRUE);", compressAfter)).Error; err != nil {
+		return fmt.Errorf("failed to add check_results compression policy: %w", err)
+	}
+	if err := s.db.Exec(fmt.Sprintf("SELECT add_retention_policy('check_results', INTERVAL '%d days', if_not_exists => TRUE);", retention)).Error; err != nil {
+		return fmt.Errorf("failed to add check_results retention policy: %w", err)
+	}
+
+	return nil
+}
+
+func envDays(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 {
+		log.Printf("Invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return days
 }
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// trackLatency counts consecutive successful checks slower than the
+// product's degraded threshold and opens a degraded incident once the
+// streak reaches DegradedAfter. The first fast check resolves it again.
+func (ps *PingService) trackLatency(item *PingItem, latency time.Duration) {
+	threshold := item.Config.DegradedThreshold
+	if threshold > 0 && latency > threshold {
+		item.SlowCount++
+		if !item.IsDegraded && item.SlowCount >= item.Config.DegradedAfter {
+			if ps.underMaintenance(item.ProductID) {
+				return
+			}
+			reason := fmt.Sprintf("response time %v exceeded the %v threshold for %d consecutive checks",
+				latency.Round(time.Millisecond), threshold, item.SlowCount)
+			log.Printf("Product %d marked as degraded: %s", item.ProductID, reason)
+			if ps.markServiceDegraded(item.ProductID, reason) {
+				item.IsDegraded = true
+			}
+		}
+		return
+	}
+
+	item.SlowCount = 0
+	if item.IsDegraded {
+		ps.resolveDegraded(item.ProductID)
+		item.IsDegraded = false
+	}
+}
+
+func (ps *PingService) markServiceDegraded(productID uint, reason string) bool {
+	db := ps.db.GetDB()
+
+	var existing Downtime
+	err := db.Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDegraded).
+		First(&existing).Error
+	if err == nil {
+		log.Printf("Product %d already has an open degraded incident", productID)
+		return true
+	}
+
+	var product Product
+	if err := db.Preload("User").First(&product, productID).Error; err != nil {
+		log.Printf("Failed to get product and user info for product %d: %v", productID, err)
+		return false
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		incident := Downtime{
+			ProductID:          productID,
+			StartTime:          now,
+			Status:             IncidentTypeDegraded,
+			IncidentType:       IncidentTypeDegraded,
+			IsNotificationSent: true,
+			FailureReason:      reason,
+		}
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+
+		return enqueueNotification(tx, NotificationEvent{
+			ProductID: productID,
+			UserEmail: product.User.Email,
+			Timestamp: now,
+			EventType: "service_degraded",
+			Message:   fmt.Sprintf("Service %s is degraded", product.Name),
+			Reason:    reason,
+		})
+	})
+	if err != nil {
+		log.Printf("Failed to record degraded incident for product %d: %v", productID, err)
+		return false
+	}
+	ps.outbox.Notify()
+	return true
+}
+
+// resolveDegraded closes the open degraded incident, if any. No
+// notification is sent; the degraded alert is informational.
+func (ps *PingService) resolveDegraded(productID uint) {
+	now := time.Now()
+	result := ps.db.GetDB().Model(&Downtime{}).
+		Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDegraded).
+		Updates(map[string]interface{}{"end_time": now, "status": "up"})
+	if result.Error != nil {
+		log.Printf("Failed to resolve degraded incident for product %d: %v", productID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Product %d is no longer degraded", productID)
+	}
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// openDownUpstream selects the open down incidents of the products a product
+// directly depends on.
+const openDownUpstream = "incident_type = ? AND end_time IS NULL AND product_id IN (SELECT depends_on_id FROM product_dependencies WHERE product_id = ?)"
+
+// findCauseDowntime returns the root incident a new downtime of productID is
+// attributed to: the earliest open downtime of a product it depends on, or
+// that downtime's own cause. It returns nil if no upstream product is down.
+func findCauseDowntime(tx *gorm.DB, productID uint) (*uint, error) {
+	var upstream Downtime
+	err := tx.Select("id", "cause_downtime_id").
+		Where(openDownUpstream, IncidentTypeDown, productID).
+		Order("start_time").
+		First(&upstream).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upstream.CauseDowntimeID != nil {
+		return upstream.CauseDowntimeID, nil
+	}
+	return &upstream.ID, nil
+}
+
+// isDowntimeOpen reports whether the incident with the given ID is still open.
+func isDowntimeOpen(tx *gorm.DB, downtimeID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&Downtime{}).Where("id = ? AND end_time IS NULL", downtimeID).Count(&count).Error
+	return count > 0, err
+}
+
+// linkDependentDowntimes attributes the open downtimes of productID's direct
+// dependents, which were detected before productID itself went down, to the
+// root incident, along with everything already attributed to them. Their
+// alerts have gone out already; the link is for analytics.
+func linkDependentDowntimes(tx *gorm.DB, productID, rootID uint) error {
+	var ids []uint
+	if err := tx.Model(&Downtime{}).
+		Where("incident_type = ? AND end_time IS NULL AND cause_downtime_id IS NULL AND id != ?", IncidentTypeDown, rootID).
+		Where("product_id IN (SELECT product_id FROM product_dependencies WHERE depends_on_id = ?)", productID).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&Downtime{}).
+		Where("id IN ? OR cause_downtime_id IN ?", ids, ids).
+		Update("cause_downtime_id", rootID).Error
+}
+
+// dependentNames returns the names of all products that directly or
+// transitively depend on productID.
+func dependentNames(tx *gorm.DB, productID uint) ([]string, error) {
+	var names []string
+	err := tx.Raw(`WITH RECURSIVE dependents AS (
+		SELECT product_id FROM product_dependencies WHERE depends_on_id = ?
+		UNION
+		SELECT d.product_id FROM product_dependencies d JOIN dependents ON d.depends_on_id = dependents.product_id
+	)
+	SELECT name FROM products WHERE id IN (SELECT product_id FROM dependents) AND id != ? ORDER BY name`, productID, productID).
+		Scan(&names).Error
+	return names, err
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordTransition remembers a confirmed up/down change of the product and
+// starts flapping once FlapThreshold changes fall within FlapWindow. It must
+// run before the change itself is recorded, so that the transition that
+// starts flapping is already silenced.
+func (ps *PingService) recordTransition(item *PingItem) {
+	now := time.Now()
+	item.Transitions = append(recentTransitions(item, now), now)
+
+	if !item.IsFlapping && len(item.Transitions) >= item.Config.FlapThreshold {
+		reason := fmt.Sprintf("%d state changes within %v", len(item.Transitions), item.Config.FlapWindow)
+		log.Printf("Product %d marked as flapping: %s", item.ProductID, reason)
+		if ps.markServiceFlapping(item.ProductID, reason) {
+			item.IsFlapping = true
+		}
+	}
+}
+
+// checkFlapping ends flapping once a whole FlapWindow passed without a
+// state change.
+func (ps *PingService) checkFlapping(item *PingItem) {
+	item.Transitions = recentTransitions(item, time.Now())
+	if item.IsFlapping && len(item.Transitions) == 0 {
+		if ps.resolveFlapping(item.ProductID, item.IsDown) {
+			item.IsFlapping = false
+		}
+	}
+}
+
+func recentTransitions(item *PingItem, now time.Time) []time.Time {
+	cutoff := now.Add(-item.Config.FlapWindow)
+	i := 0
+	for i < len(item.Transitions) && !item.Transitions[i].After(cutoff) {
+		i++
+	}
+	return item.Transitions[i:]
+}
+
+// markServiceFlapping opens a flapping incident with a single notification.
+// Downtimes keep being recorded while it is open, but are not announced.
+func (ps *PingService) markServiceFlapping(productID uint, reason string) bool {
+	db := ps.db.GetDB()
+
+	var product Product
+	if err := db.Preload("User").First(&product, productID).Error; err != nil {
+		log.Printf("Failed to get product and user info for product %d: %v", productID, err)
+		return false
+	}
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Downtime{}).
+			Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeFlapping).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			log.Printf("Product %d already has an open flapping incident", productID)
+			return nil
+		}
+
+		incident := Downtime{
+			ProductID:          productID,
+			StartTime:          now,
+			Status:             IncidentTypeFlapping,
+			IncidentType:       IncidentTypeFlapping,
+			IsNotificationSent: true,
+			FailureReason:      reason,
+		}
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+
+		return enqueueNotification(tx, NotificationEvent{
+			ProductID: productID,
+			UserEmail: product.User.Email,
+			Timestamp: now,
+			EventType: "service_flapping",
+			Message:   fmt.Sprintf("Service %s is flapping", product.Name),
+			Reason:    reason,
+		})
+	})
+	if err != nil {
+		log.Printf("Failed to record flapping incident for product %d: %v", productID, err)
+		return false
+	}
+	ps.outbox.Notify()
+	return true
+}
+
+// resolveFlapping closes the flapping incident and sends one notification
+// with the state the product settled in. A downtime that is still open was
+// covered by that notification and is not announced separately.
+func (ps *PingService) resolveFlapping(productID uint, isDown bool) bool {
+	db := ps.db.GetDB()
+
+	var product Product
+	if err := db.Preload("User").First(&product, productID).Error; err != nil {
+		log.Printf("Failed to get product and user info for product %d: %v", productID, err)
+		return false
+	}
+
+	state := "up"
+	if isDown {
+		state = "down"
+	}
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Downtime{}).
+			Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeFlapping).
+			Updates(map[string]interface{}{"end_time": now, "status": "up"})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if isDown {
+			if err := tx.Model(&Downtime{}).
+				Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDown).
+				Update("is_notification_sent", true).Error; err != nil {
+				return err
+			}
+		}
+
+		return enqueueNotification(tx, NotificationEvent{
+			ProductID: productID,
+			UserEmail: product.User.Email,
+			Timestamp: now,
+			EventType: "service_flapping_ended",
+			Message:   fmt.Sprintf("Service %s stopped flapping and is %s", product.Name, state),
+		})
+	})
+	if err != nil {
+		log.Printf("Failed to resolve flapping incident for product %d: %v", productID, err)
+		return false
+	}
+	ps.outbox.Notify()
+
+	log.Printf("Product %d is no longer flapping and is %s", productID, state)
+	return true
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+const (
+	ComponentPass = "pass"
+	ComponentWarn = "warn"
+	ComponentFail = "fail"
+
+	// MaxHealthComponents caps how many components are kept per check.
+	MaxHealthComponents = 50
+)
+
+// isJSONContentType reports whether a response may carry a health document,
+// e.g. application/json, application/health+json or Spring's
+// application/vnd.spring-boot.actuator.v3+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// parseHealthComponents returns the component statuses of a health document
+// in the application/health+json draft format ("checks"), the Spring Boot
+// actuator format ("components", or "details" before Boot 2.2), the
+// MicroProfile format ("checks" as a list) or the ASP.NET Core format
+// ("entries"). Any other body yields nil. Failing components come first.
+func parseHealthComponents(body []byte) []ComponentStatus {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	if _, ok := doc["status"].(string); !ok {
+		return nil
+	}
+
+	var components []ComponentStatus
+	switch {
+	case doc["checks"] != nil:
+		components = parseHealthChecks(doc["checks"])
+	case doc["components"] != nil:
+		components = parseNamedComponents("", doc["components"])
+	case doc["entries"] != nil:
+		components = parseNamedComponents("", doc["entries"])
+	case doc["details"] != nil:
+		components = parseNamedComponents("", doc["details"])
+	}
+	if len(components) == 0 {
+		return nil
+	}
+
+	rank := map[string]int{ComponentFail: 0, ComponentWarn: 1, ComponentPass: 2}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Status != components[j].Status {
+			return rank[components[i].Status] < rank[components[j].Status]
+		}
+		return components[i].Name < components[j].Name
+	})
+	if len(components) > MaxHealthComponents {
+		components = components[:MaxHealthComponents]
+	}
+	return components
+}
+
+// parseHealthChecks handles "checks" as either the health+json map of
+// "component:measurement" keys to lists of results, or a list of named
+// results.
+func parseHealthChecks(checks interface{}) []ComponentStatus {
+	var components []ComponentStatus
+	switch checks := checks.(type) {
+	case map[string]interface{}:
+		for name, results := range checks {
+			list, ok := results.([]interface{})
+			if !ok {
+				continue
+			}
+			component := ComponentStatus{Name: name, Status: ComponentPass}
+			for _, result := range list {
+				fields, ok := result.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				status := normalizeHealthStatus(fields["status"])
+				if worseHealthStatus(status, component.Status) {
+					component.Status = status
+				}
+				if component.Output == "" {
+					component.Output = healthOutput(fields)
+				}
+			}
+			components = append(components, component)
+		}
+	case []interface{}:
+		for _, check := range checks {
+			fields, ok := check.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := fields["name"].(string)
+			if name == "" {
+				continue
+			}
+			components = append(components, ComponentStatus{
+				Name:   name,
+				Status: normalizeHealthStatus(fields["status"]),
+				Output: healthOutput(fields),
+			})
+		}
+	}
+	return components
+}
+
+// parseNamedComponents handles maps of component names to objects with a
+// status. Spring nests the components of a group, e.g. several datasources
+// under "db"; those are flattened to "db/primary".
+func parseNamedComponents(prefix string, components interface{}) []ComponentStatus {
+	named, ok := components.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var result []ComponentStatus
+	for name, value := range named {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := fields["status"]; !ok {
+			continue
+		}
+		if nested := parseNamedComponents(prefix+name+"/", fields["components"]); len(nested) > 0 {
+			result = append(result, nested...)
+			continue
+		}
+		result = append(result, ComponentStatus{
+			Name:   prefix + name,
+			Status: normalizeHealthStatus(fields["status"]),
+			Output: healthOutput(fields),
+		})
+	}
+	return result
+}
+
+// normalizeHealthStatus maps the status values of the supported formats to
+// pass, warn or fail. Unrecognised values count as warn.
+func normalizeHealthStatus(value interface{}) string {
+	status, _ := value.(string)
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "pass", "ok", "up", "healthy":
+		return ComponentPass
+	case "fail", "error", "down", "unhealthy", "out_of_service":
+		return ComponentFail
+	default:
+		return ComponentWarn
+	}
+}
+
+func worseHealthStatus(status, than string) bool {
+	return status == ComponentFail || (status == ComponentWarn && than == ComponentPass)
+}
+
+// healthOutput picks the human readable detail of a component, if any.
+func healthOutput(fields map[string]interface{}) string {
+	for _, key := range []string{"output", "description", "exception", "error", "message"} {
+		if value, ok := fields[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	if details, ok := fields["details"].(map[string]interface{}); ok {
+		if value, ok := details["error"].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// formatComponents renders components as "db: fail (timeout), cache: pass".
+func formatComponents(components []ComponentStatus) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		parts[i] = fmt.Sprintf("%s: %s", c.Name, c.Status)
+		if c.Output != "" && c.Status != ComponentPass {
+			parts[i] += " (" + c.Output + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIsJSONContentType(t *testing.T) {
+	tests := map[string]bool{
+		"application/json":                             true,
+		"application/json; charset=utf-8":              true,
+		"application/health+json":                      true,
+		"application/vnd.spring-boot.actuator.v3+json": true,
+		"text/plain":                                   false,
+		"text/html; charset=utf-8":                     false,
+		"":                                             false,
+		"application/json; charset=\"unterminated":        false,
+		"application/jsonp":                               false,
+		"application/vnd.spring-boot.actuator.v3+json;x=": false,
+	}
+	for contentType, want := range tests {
+		if got := isJSONContentType(contentType); got != want {
+			t.Errorf("isJSONContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
+
+func TestParseHealthComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // formatComponents of the result
+	}{
+		{
+			name: "health+json checks",
+			body: `{"status":"warn","checks":{
+				"db:responseTime":[{"status":"pass"},{"status":"fail","output":"replica lagging"}],
+				"cache:hits":[{"status":"warn","output":"low hit rate"}],
+				"disk:free":[{"status":"pass"}]}}`,
+			want: "db:responseTime: fail (replica lagging), cache:hits: warn (low hit rate), disk:free: pass",
+		},
+		{
+			name: "spring components",
+			body: `{"status":"DOWN","components":{
+				"diskSpace":{"status":"UP","details":{"free":1}},
+				"redis":{"status":"DOWN","details":{"error":"connection refused"}},
+				"ping":{"status":"UP"}}}`,
+			want: "redis: fail (connection refused), diskSpace: pass, ping: pass",
+		},
+		{
+			name: "spring nested group",
+			body: `{"status":"UP","components":{"db":{"status":"UP","components":{
+				"primary":{"status":"UP"},"reporting":{"status":"OUT_OF_SERVICE"}}}}}`,
+			want: "db/reporting: fail, db/primary: pass",
+		},
+		{
+			name: "spring before 2.2",
+			body: `{"status":"UP","details":{"mail":{"status":"UNKNOWN"}}}`,
+			want: "mail: warn",
+		},
+		{
+			name: "microprofile",
+			body: `{"status":"DOWN","checks":[{"name":"kafka","status":"DOWN"},{"name":"db","status":"UP"},{"status":"UP"}]}`,
+			want: "kafka: fail, db: pass",
+		},
+		{
+			name: "asp.net core",
+			body: `{"status":"Degraded","entries":{"sql":{"status":"Healthy"},"blob":{"status":"Unhealthy","description":"timeout"}}}`,
+			want: "blob: fail (timeout), sql: pass",
+		},
+		{
+			name: "entries that are not objects are skipped",
+			body: `{"status":"UP","components":{"version":"1.2.3","db":{"status":"UP"},"meta":{"region":"eu"}}}`,
+			want: "db: pass",
+		},
+		{
+			name: "checks that are not lists are skipped",
+			body: `{"status":"pass","checks":{"db":"pass","cache":[{"status":"pass"}],"queue":[42]}}`,
+			want: "cache: pass, queue: pass",
+		},
+		{name: "status only", body: `{"status":"UP"}`},
+		{name: "no status", body: `{"checks":{"db":[{"status":"pass"}]}}`},
+		{name: "numeric status", body: `{"status":1,"checks":{"db":[{"status":"pass"}]}}`},
+		{name: "empty components", body: `{"status":"UP","components":{}}`},
+		{name: "components not an object", body: `{"status":"UP","components":["db"]}`},
+		{name: "array document", body: `[{"status":"UP"}]`},
+		{name: "malformed JSON", body: `{"status":"UP","components":{`},
+		{name: "HTML", body: `<html><body>OK</body></html>`},
+		{name: "empty body", body: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := parseHealthComponents([]byte(tt.body))
+			if tt.want == "" {
+				if components != nil {
+					t.Fatalf("parseHealthComponents = %v, want nil", components)
+				}
+				return
+			}
+			if got := formatComponents(components); got != tt.want {
+				t.Fatalf("parseHealthComponents = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHealthComponentsLimit(t *testing.T) {
+	var entries []string
+	for i := 0; i < MaxHealthComponents+10; i++ {
+		status := "UP"
+		if i == MaxHealthComponents+5 {
+			status = "DOWN"
+		}
+		entries = append(entries, fmt.Sprintf(`"c%03d":{"status":%q}`, i, status))
+	}
+	body := `{"status":"DOWN","components":{` + strings.Join(entries, ",") + `}}`
+
+	components := parseHealthComponents([]byte(body))
+	if len(components) != MaxHealthComponents {
+		t.Fatalf("got %d components, want %d", len(components), MaxHealthComponents)
+	}
+	// Failing components sort first, so they survive the cut
+	if components[0].Status != ComponentFail {
+		t.Fatalf("first component = %+v, want the failing one", components[0])
+	}
+}
+
+func TestNormalizeHealthStatus(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"pass", ComponentPass},
+		{"UP", ComponentPass},
+		{" Healthy ", ComponentPass},
+		{"ok", ComponentPass},
+		{"fail", ComponentFail},
+		{"DOWN", ComponentFail},
+		{"Unhealthy", ComponentFail},
+		{"OUT_OF_SERVICE", ComponentFail},
+		{"error", ComponentFail},
+		{"warn", ComponentWarn},
+		{"Degraded", ComponentWarn},
+		{"UNKNOWN", ComponentWarn},
+		{"", ComponentWarn},
+		{nil, ComponentWarn},
+		{true, ComponentWarn},
+	}
+	for _, tt := range tests {
+		if got := normalizeHealthStatus(tt.value); got != tt.want {
+			t.Errorf("normalizeHealthStatus(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -7,26 +7,104 @@ import (
 )
 
 type PingItem struct {
-	ProductID  uint
-	HealthAPI  string
-	NextPingAt time.Time
-	RetryCount int
-	IsDown     bool
+	ProductID   uint
+	HealthAPI   string
+	Config      CheckSettings
+	Version     time.Time // latest updated_at of the product and its check config
+	NextPingAt  time.Time
+	RetryCount  int
+	IsDown      bool
+	LastFailure string
+	Components  []ComponentStatus
+	SlowCount   int // consecutive successful checks over the degraded threshold
+	IsDegraded  bool
+	Transitions []time.Time // confirmed up/down changes within the flap window
+	IsFlapping  bool
+
+	index      int  // position in the heap, or indexChecking / indexWaiting
+	restored   bool // state was restored from an open downtime and awaits its first check
+	suppressed bool // down alert held back while an upstream product is down
+
+	reportedAt time.Time // last verdict sent to the coordinator in probe mode
+	reportedUp bool      // whether that verdict was a success
+
+	checked chan<- *CheckOutcome // receives the outcome of an on-demand check
 }
 
+const (
+	// indexChecking marks an item a worker is checking.
+	indexChecking = -1
+	// indexWaiting marks a replacement that waits for the check of the
+	// item it replaces to finish.
+	indexWaiting = -2
+)
+
+// inheritState carries the health state of a product over to a replacement
+// item built from updated product data.
+func (item *PingItem) inheritState(from *PingItem) {
+	item.RetryCount = from.RetryCount
+	item.IsDown = from.IsDown
+	item.LastFailure = from.LastFailure
+	item.Components = from.Components
+	item.SlowCount = from.SlowCount
+	item.IsDegraded = from.IsDegraded
+	item.Transitions = from.Transitions
+	item.IsFlapping = from.IsFlapping
+	item.restored = from.restored
+	item.suppressed = from.suppressed
+	item.reportedAt = from.reportedAt
+	item.reportedUp = from.reportedUp
+	if item.HealthAPI == from.HealthAPI {
+		item.NextPingAt = from.NextPingAt
+	}
+}
+
+// scheduleNext sets the next regular check one interval plus jitter from
+// now.
+func (item *PingItem) scheduleNext() {
+	item.NextPingAt = time.Now().Add(item.Config.Interval + item.Config.ScheduleJitter())
+}
+
+// PingHeap orders products by their next check. It also tracks which item is
+// current for each product, including items a worker is checking, so that
+// updates and deletions can be applied while a check is in flight.
 type PingHeap struct {
-	items []*PingItem
-	mutex sync.RWMutex
+	items     []*PingItem
+	byProduct map[uint]*PingItem
+	mutex     sync.RWMutex
+
+	// wake is signalled when an item becomes the earliest one, so the
+	// scheduler can sleep until the next check is due.
+	wake chan struct{}
 }
 
 func NewPingHeap() *PingHeap {
 	h := &PingHeap{
-		items: make([]*PingItem, 0),
+		items:     make([]*PingItem, 0),
+		byProduct: make(map[uint]*PingItem),
+		wake:      make(chan struct{}, 1),
 	}
 	heap.Init(h)
 	return h
 }
 
+// Wake returns the channel that signals a new earliest item.
+func (h *PingHeap) Wake() <-chan struct{} {
+	return h.wake
+}
+
+// notifyHead wakes the scheduler if item moved to the top of the heap. The
+// caller must hold the lock.
+func (h *PingHeap) notifyHead(item *PingItem) {
+	if item.index != 0 {
+		return
+	}
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
 func (h *PingHeap) Len() int {
 	return len(h.items)
 }
@@ -37,42 +115,191 @@ func (h *PingHeap) Less(i, j int) bool {
 
 func (h *PingHeap) Swap(i, j int) {
 	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
 }
 
 func (h *PingHeap) Push(x interface{}) {
-	h.items = append(h.items, x.(*PingItem))
+	item := x.(*PingItem)
+	item.index = len(h.items)
+	h.items = append(h.items, item)
 }
 
 func (h *PingHeap) Pop() interface{} {
 	old := h.items
 	n := len(old)
 	item := old[n-1]
+	old[n-1] = nil
+	item.index = indexChecking
 	h.items = old[0 : n-1]
 	return item
 }
 
-func (h *PingHeap) SafePush(item *PingItem) {
+// SafeAdd starts tracking a product. It is a no-op if the product is
+// already tracked.
+func (h *PingHeap) SafeAdd(item *PingItem) bool {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
+	if _, ok := h.byProduct[item.ProductID]; ok {
+		return false
+	}
+	h.byProduct[item.ProductID] = item
 	heap.Push(h, item)
+	h.notifyHead(item)
+	return true
 }
 
-func (h *PingHeap) SafePop() *PingItem {
+// SafeUpsert replaces the tracked item for a product, keeping its health
+// state. If the current item is being checked, the replacement takes over
+// when that check reschedules.
+func (h *PingHeap) SafeUpsert(item *PingItem) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	if h.Len() == 0 {
+
+	current, ok := h.byProduct[item.ProductID]
+	h.byProduct[item.ProductID] = item
+	switch {
+	case !ok:
+		heap.Push(h, item)
+		h.notifyHead(item)
+	case current.index >= 0:
+		item.inheritState(current)
+		heap.Remove(h, current.index)
+		heap.Push(h, item)
+		h.notifyHead(item)
+	default:
+		item.index = indexWaiting
+	}
+}
+
+// SafeRemove stops tracking a product. An item that is being checked is
+// dropped when it reschedules.
+func (h *PingHeap) SafeRemove(productID uint) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	current, ok := h.byProduct[productID]
+	if !ok {
+		return false
+	}
+	delete(h.byProduct, productID)
+	if current.index >= 0 {
+		heap.Remove(h, current.index)
+	}
+	return true
+}
+
+// SafePush reschedules an item after its check. Items for removed products
+// are dropped and replaced items hand their state to the replacement. An
+// item of a product that was removed and added again during the check is
+// dropped as well; the new item is scheduled on its own.
+func (h *PingHeap) SafePush(item *PingItem) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	current, ok := h.byProduct[item.ProductID]
+	switch {
+	case !ok:
+		return
+	case current == item:
+		heap.Push(h, item)
+		h.notifyHead(item)
+	case current.index == indexWaiting:
+		current.inheritState(item)
+		heap.Push(h, current)
+		h.notifyHead(current)
+	}
+}
+
+// SafeCheckNow moves the next check of a product forward to now. Nothing
+// changes while the product is being checked.
+func (h *PingHeap) SafeCheckNow(productID uint) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	current, ok := h.byProduct[productID]
+	if !ok || current.index < 0 {
+		return false
+	}
+	current.NextPingAt = time.Now()
+	heap.Fix(h, current.index)
+	h.notifyHead(current)
+	return true
+}
+
+// SafeTake removes a product's item from the heap for an immediate check, as
+// if it had been popped when due. It returns nil if the product is not
+// tracked or is already being checked.
+func (h *PingHeap) SafeTake(productID uint) *PingItem {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	current, ok := h.byProduct[productID]
+	if !ok || current.index < 0 {
 		return nil
 	}
-	return heap.Pop(h).(*PingItem)
+	heap.Remove(h, current.index)
+	return current
+}
+
+// PingItemSnapshot is a copy of a tracked item. The health state of an item
+// that is being checked belongs to the worker, so only its product and check
+// settings are copied.
+type PingItemSnapshot struct {
+	PingItem
+	Checking bool
 }
 
-func (h *PingHeap) SafePeek() *PingItem {
+// SafeSnapshot copies every tracked item, in no particular order.
+func (h *PingHeap) SafeSnapshot() []PingItemSnapshot {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
+
+	snapshots := make([]PingItemSnapshot, 0, len(h.byProduct))
+	for _, item := range h.byProduct {
+		if item.index < 0 {
+			snapshots = append(snapshots, PingItemSnapshot{
+				PingItem: PingItem{ProductID: item.ProductID, HealthAPI: item.HealthAPI, Config: item.Config, Version: item.Version},
+				Checking: true,
+			})
+			continue
+		}
+		snapshots = append(snapshots, PingItemSnapshot{PingItem: *item})
+	}
+	return snapshots
+}
+
+func (h *PingHeap) SafeContains(productID uint) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	_, ok := h.byProduct[productID]
+	return ok
+}
+
+// SafeVersions returns the version of every tracked product.
+func (h *PingHeap) SafeVersions() map[uint]time.Time {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	versions := make(map[uint]time.Time, len(h.byProduct))
+	for id, item := range h.byProduct {
+		versions[id] = item.Version
+	}
+	return versions
+}
+
+// SafePopDue removes and returns the earliest item if it is due at now.
+// Otherwise it returns nil and when the earliest item is due, which is zero
+// for an empty heap.
+func (h *PingHeap) SafePopDue(now time.Time) (*PingItem, time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 	if h.Len() == 0 {
-		return nil
+		return nil, time.Time{}
+	}
+	if next := h.items[0].NextPingAt; next.After(now) {
+		return nil, next
 	}
-	return h.items[0]
+	return heap.Pop(h).(*PingItem), time.Time{}
 }
 
 func (h *PingHeap) SafeLen() int {
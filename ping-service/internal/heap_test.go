@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// checkHeapInvariants verifies that every queued item knows its position,
+// the heap is ordered and the index tracks exactly the queued items plus
+// those being checked.
+func checkHeapInvariants(t *testing.T, h *PingHeap) {
+	t.Helper()
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for i, item := range h.items {
+		if item.index != i {
+			t.Fatalf("item of product %d at position %d has index %d", item.ProductID, i, item.index)
+		}
+		if h.byProduct[item.ProductID] != item {
+			t.Fatalf("queued item of product %d is not the tracked one", item.ProductID)
+		}
+		if parent := (i - 1) / 2; i > 0 && h.items[i].NextPingAt.Before(h.items[parent].NextPingAt) {
+			t.Fatalf("item at position %d is due before its parent", i)
+		}
+	}
+	queued := 0
+	for productID, item := range h.byProduct {
+		if item.ProductID != productID {
+			t.Fatalf("product %d tracks the item of product %d", productID, item.ProductID)
+		}
+		if item.index >= 0 {
+			queued++
+		}
+	}
+	if queued != len(h.items) {
+		t.Fatalf("%d tracked items are queued, the heap holds %d", queued, len(h.items))
+	}
+}
+
+func newTestItem(productID uint, nextPingAt time.Time) *PingItem {
+	return &PingItem{ProductID: productID, Config: DefaultCheckSettings(), NextPingAt: nextPingAt}
+}
+
+func TestHeapPopOrder(t *testing.T) {
+	h := NewPingHeap()
+	now := time.Now()
+	for _, offset := range []int{5, 1, 4, 2, 3} {
+		h.SafeAdd(newTestItem(uint(offset), now.Add(time.Duration(offset)*time.Second)))
+		checkHeapInvariants(t, h)
+	}
+	if h.SafeAdd(newTestItem(1, now)) {
+		t.Fatal("SafeAdd replaced a tracked product")
+	}
+
+	if item, next := h.SafePopDue(now); item != nil || !next.Equal(now.Add(time.Second)) {
+		t.Fatalf("SafePopDue before anything is due = %v, %v", item, next)
+	}
+	for want := uint(1); want <= 5; want++ {
+		item, _ := h.SafePopDue(now.Add(time.Minute))
+		if item == nil || item.ProductID != want {
+			t.Fatalf("popped %v, want product %d", item, want)
+		}
+		if item.index != indexChecking {
+			t.Fatalf("popped item has index %d", item.index)
+		}
+		checkHeapInvariants(t, h)
+	}
+	if item, next := h.SafePopDue(now.Add(time.Minute)); item != nil || !next.IsZero() {
+		t.Fatalf("SafePopDue on an empty heap = %v, %v", item, next)
+	}
+	if !h.SafeContains(3) {
+		t.Fatal("products being checked must stay tracked")
+	}
+}
+
+func TestHeapUpsertRemoveCheckNow(t *testing.T) {
+	h := NewPingHeap()
+	now := time.Now()
+	for id := uint(1); id <= 3; id++ {
+		h.SafeAdd(newTestItem(id, now.Add(time.Duration(id)*time.Minute)))
+	}
+
+	replacement := newTestItem(3, now)
+	h.mutex.Lock()
+	h.byProduct[3].RetryCount = 2
+	h.byProduct[3].IsDown = true
+	h.mutex.Unlock()
+	h.SafeUpsert(replacement)
+	checkHeapInvariants(t, h)
+	if replacement.RetryCount != 2 || !replacement.IsDown {
+		t.Fatalf("replacement did not inherit the health state: %+v", replacement)
+	}
+	if !replacement.NextPingAt.Equal(now.Add(3 * time.Minute)) {
+		t.Fatalf("replacement for the same target must keep the schedule, got %v", replacement.NextPingAt)
+	}
+
+	if !h.SafeRemove(2) || h.SafeRemove(2) || h.SafeContains(2) {
+		t.Fatal("SafeRemove must remove a product once")
+	}
+	checkHeapInvariants(t, h)
+
+	if !h.SafeCheckNow(3) {
+		t.Fatal("SafeCheckNow failed for a queued product")
+	}
+	checkHeapInvariants(t, h)
+	if item, _ := h.SafePopDue(time.Now()); item != replacement {
+		t.Fatalf("popped %v, want the product checked now", item)
+	}
+	if h.SafeCheckNow(3) || h.SafeTake(3) != nil {
+		t.Fatal("a product being checked cannot be checked now or taken")
+	}
+
+	taken := h.SafeTake(1)
+	if taken == nil || taken.index != indexChecking {
+		t.Fatalf("SafeTake = %v", taken)
+	}
+	checkHeapInvariants(t, h)
+	if h.SafeLen() != 0 {
+		t.Fatalf("heap holds %d items, want none", h.SafeLen())
+	}
+
+	h.SafePush(taken)
+	h.SafePush(replacement)
+	checkHeapInvariants(t, h)
+	if h.SafeLen() != 2 {
+		t.Fatalf("heap holds %d items, want 2", h.SafeLen())
+	}
+}
+
+func TestHeapInFlightItem(t *testing.T) {
+	now := time.Now()
+
+	t.Run("removed while checked", func(t *testing.T) {
+		h := NewPingHeap()
+		h.SafeAdd(newTestItem(1, now))
+		item, _ := h.SafePopDue(now)
+
+		h.SafeRemove(1)
+		h.SafePush(item)
+		checkHeapInvariants(t, h)
+		if h.SafeLen() != 0 || h.SafeContains(1) {
+			t.Fatal("the item of a removed product was pushed back")
+		}
+	})
+
+	t.Run("updated while checked", func(t *testing.T) {
+		h := NewPingHeap()
+		h.SafeAdd(newTestItem(1, now))
+		stale, _ := h.SafePopDue(now)
+
+		replacement := newTestItem(1, now.Add(time.Hour))
+		replacement.Version = now
+		h.SafeUpsert(replacement)
+		checkHeapInvariants(t, h)
+		if replacement.index != indexWaiting || h.SafeLen() != 0 {
+			t.Fatal("the replacement must wait for the check in flight")
+		}
+		if versions := h.SafeVersions(); !versions[1].Equal(now) {
+			t.Fatalf("tracked version = %v, want the replacement's", versions[1])
+		}
+
+		stale.IsDown = true
+		stale.NextPingAt = now.Add(time.Minute)
+		h.SafePush(stale)
+		checkHeapInvariants(t, h)
+		if stale.index != indexChecking || replacement.index != 0 {
+			t.Fatal("the stale item was pushed instead of its replacement")
+		}
+		if !replacement.IsDown || !replacement.NextPingAt.Equal(stale.NextPingAt) {
+			t.Fatalf("replacement did not take over the checked state: %+v", replacement)
+		}
+
+		// A second push of the stale item must not queue it next to the
+		// replacement
+		h.SafePush(stale)
+		checkHeapInvariants(t, h)
+		if h.SafeLen() != 1 {
+			t.Fatalf("heap holds %d items, want 1", h.SafeLen())
+		}
+	})
+
+	t.Run("removed and added again while checked", func(t *testing.T) {
+		h := NewPingHeap()
+		h.SafeAdd(newTestItem(1, now))
+		stale, _ := h.SafePopDue(now)
+
+		h.SafeRemove(1)
+		readded := newTestItem(1, now)
+		h.SafeAdd(readded)
+		if item, _ := h.SafePopDue(now); item != readded {
+			t.Fatalf("popped %v, want the re-added item", item)
+		}
+
+		// The stale check finishes while the new item is checked too
+		h.SafePush(stale)
+		checkHeapInvariants(t, h)
+		if h.SafeLen() != 0 {
+			t.Fatal("the item being checked was queued by the stale check")
+		}
+		h.SafePush(readded)
+		checkHeapInvariants(t, h)
+		if h.SafeLen() != 1 {
+			t.Fatalf("heap holds %d items, want 1", h.SafeLen())
+		}
+	})
+
+	t.Run("updated twice while checked", func(t *testing.T) {
+		h := NewPingHeap()
+		h.SafeAdd(newTestItem(1, now))
+		stale, _ := h.SafePopDue(now)
+
+		h.SafeUpsert(newTestItem(1, now))
+		latest := newTestItem(1, now)
+		h.SafeUpsert(latest)
+		h.SafePush(stale)
+		checkHeapInvariants(t, h)
+		if latest.index != 0 || h.SafeLen() != 1 {
+			t.Fatal("only the latest replacement may be queued")
+		}
+	})
+}
+
+func TestHeapRandomOperations(t *testing.T) {
+	h := NewPingHeap()
+	now := time.Now()
+	var inFlight []*PingItem
+
+	for i := 0; i < 5000; i++ {
+		productID := rand.UintN(50) + 1
+		switch op := rand.IntN(7); op {
+		case 0:
+			h.SafeAdd(newTestItem(productID, now.Add(rand.N(time.Hour))))
+		case 1:
+			h.SafeUpsert(newTestItem(productID, now.Add(rand.N(time.Hour))))
+		case 2:
+			h.SafeRemove(productID)
+		case 3:
+			h.SafeCheckNow(productID)
+		case 4:
+			if item := h.SafeTake(productID); item != nil {
+				inFlight = append(inFlight, item)
+			}
+		case 5:
+			if item, _ := h.SafePopDue(now.Add(time.Hour)); item != nil {
+				inFlight = append(inFlight, item)
+			}
+		case 6:
+			if len(inFlight) > 0 {
+				j := rand.IntN(len(inFlight))
+				item := inFlight[j]
+				inFlight = append(inFlight[:j], inFlight[j+1:]...)
+				item.NextPingAt = now.Add(rand.N(time.Hour))
+				h.SafePush(item)
+			}
+		}
+		checkHeapInvariants(t, h)
+	}
+}
+
+// benchmarkProducts is the fleet size the scheduler is expected to handle.
+const benchmarkProducts = 100_000
+
+func newBenchmarkHeap(now time.Time) *PingHeap {
+	h := NewPingHeap()
+	for id := uint(1); id <= benchmarkProducts; id++ {
+		h.SafeAdd(newBenchmarkItem(id, now))
+	}
+	return h
+}
+
+func newBenchmarkItem(productID uint, now time.Time) *PingItem {
+	config := DefaultCheckSettings()
+	return &PingItem{
+		ProductID:  productID,
+		Config:     config,
+		NextPingAt: now.Add(rand.N(config.Interval)),
+	}
+}
+
+func drainWake(h *PingHeap) {
+	select {
+	case <-h.Wake():
+	default:
+	}
+}
+
+func BenchmarkHeapUpsert(b *testing.B) {
+	now := time.Now()
+	h := newBenchmarkHeap(now)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.SafeUpsert(newBenchmarkItem(rand.UintN(benchmarkProducts)+1, now))
+		drainWake(h)
+	}
+}
+
+func BenchmarkHeapRemoveAdd(b *testing.B) {
+	now := time.Now()
+	h := newBenchmarkHeap(now)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		productID := rand.UintN(benchmarkProducts) + 1
+		h.SafeRemove(productID)
+		h.SafeAdd(newBenchmarkItem(productID, now))
+		drainWake(h)
+	}
+}
+
+// BenchmarkHeapPopReschedule measures the scheduler's work per check: take
+// the due item and push it back one interval later.
+func BenchmarkHeapPopReschedule(b *testing.B) {
+	h := newBenchmarkHeap(time.Now().Add(-time.Hour))
+	far := time.Now().Add(24 * time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		item, _ := h.SafePopDue(far)
+		item.scheduleNext()
+		h.SafePush(item)
+		drainWake(h)
+	}
+}
+
+// BenchmarkHeapDrainDue pops all products at once, as after a restart when
+// every check is due.
+func BenchmarkHeapDrainDue(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		now := time.Now()
+		h := newBenchmarkHeap(now.Add(-time.Hour))
+		b.StartTimer()
+		for count := 0; count < benchmarkProducts; count++ {
+			if item, _ := h.SafePopDue(now); item == nil {
+				b.Fatalf("only %d of %d products were due", count, benchmarkProducts)
+			}
+		}
+	}
+}
@@ -11,11 +11,32 @@ import (
 )
 
 type NotificationEvent struct {
+	EventID   string    `json:"event_id"`
 	ProductID uint      `json:"product_id"`
 	UserEmail string    `json:"user_email"`
 	Timestamp time.Time `json:"timestamp"`
-	EventType string    `json:"event_type"` // "service_down", "service_up"
+	EventType string    `json:"event_type"` // "service_down", "service_up", "service_degraded", "cert_expiring"
 	Message   string    `json:"message"`
+	Reason    string    `json:"reason,omitempty"`
+
+	// Components is the health document of the failed check, if any.
+	Components []ComponentStatus `json:"components,omitempty"`
+
+	// Dependents are the products whose down alerts are held back while
+	// this one is down.
+	Dependents []string `json:"dependents,omitempty"`
+
+	Certificate *CertificateDetails `json:"certificate,omitempty"`
+}
+
+// ProbeReport is a probe location's verdict for a product after its local
+// retries, consumed by the coordinator.
+type ProbeReport struct {
+	ProductID uint      `json:"product_id"`
+	Location  string    `json:"location"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
 }
 
 type KafkaProducer struct {
@@ -36,28 +57,82 @@ func NewKafkaProducer(brokers []string, topic string) *KafkaProducer {
 	}
 }
 
-func (kp *KafkaProducer) SendNotification(ctx context.Context, event NotificationEvent) error {
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
-
-	message := kafka.Message{
-		Key:   []byte(fmt.Sprintf("%d", event.ProductID)),
-		Value: eventBytes,
-		Time:  time.Now(),
+// PublishOutbox writes outbox rows as one batch, keyed by product so the
+// events of a product stay in order.
+func (kp *KafkaProducer) PublishOutbox(ctx context.Context, rows []NotificationOutbox) error {
+	messages := make([]kafka.Message, len(rows))
+	for i, row := range rows {
+		messages[i] = kafka.Message{
+			Key:   []byte(fmt.Sprintf("%d", row.ProductID)),
+			Value: []byte(row.Payload),
+			Time:  row.CreatedAt,
+		}
 	}
+	return kp.writer.WriteMessages(ctx, messages...)
+}
 
-	err = kp.writer.WriteMessages(ctx, message)
+func (kp *KafkaProducer) SendProbeReport(ctx context.Context, report ProbeReport) error {
+	reportBytes, err := json.Marshal(report)
 	if err != nil {
-		log.Printf("Failed to send notification to Kafka: %v", err)
 		return err
 	}
 
-	log.Printf("Notification sent to Kafka: ProductID=%d, EventType=%s", event.ProductID, event.EventType)
-	return nil
+	// Keyed by product so one coordinator sees every location's reports
+	return kp.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(fmt.Sprintf("%d", report.ProductID)),
+		Value: reportBytes,
+		Time:  time.Now(),
+	})
 }
 
 func (kp *KafkaProducer) Close() error {
 	return kp.writer.Close()
 }
+
+type KafkaConsumer struct {
+	reader *kafka.Reader
+}
+
+func NewKafkaConsumer(brokers []string, topic string, groupID string) *KafkaConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		Topic:          topic,
+		GroupID:        groupID,
+		MinBytes:       1,
+		MaxBytes:       10e6, // 10MB
+		CommitInterval: time.Second,
+		StartOffset:    kafka.LastOffset,
+	})
+
+	return &KafkaConsumer{
+		reader: reader,
+	}
+}
+
+func (kc *KafkaConsumer) ConsumeProbeReports(ctx context.Context, handler func(ProbeReport)) error {
+	for {
+		message, err := kc.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Error fetching probe report: %v", err)
+			continue
+		}
+
+		var report ProbeReport
+		if err := json.Unmarshal(message.Value, &report); err != nil {
+			log.Printf("Error unmarshaling probe report: %v", err)
+		} else {
+			handler(report)
+		}
+
+		if err := kc.reader.CommitMessages(ctx, message); err != nil {
+			log.Printf("Error committing probe report: %v", err)
+		}
+	}
+}
+
+func (kc *KafkaConsumer) Close() error {
+	return kc.reader.Close()
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// activeAt reports whether the window covers t.
+func (w MaintenanceWindow) activeAt(t time.Time) (bool, error) {
+	if w.Schedule == "" {
+		return w.StartTime != nil && w.EndTime != nil &&
+			!t.Before(*w.StartTime) && t.Before(*w.EndTime), nil
+	}
+
+	schedule, err := parseCron(w.Schedule)
+	if err != nil {
+		return false, err
+	}
+	location := time.UTC
+	if w.Timezone != "" {
+		if location, err = time.LoadLocation(w.Timezone); err != nil {
+			return false, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+		}
+	}
+
+	// Look for a start within the window's duration before t
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+	start := schedule.next(t.Add(-duration).Add(time.Nanosecond).In(location))
+	return !start.IsZero() && !start.After(t), nil
+}
+
+// underMaintenance reports whether a maintenance window of the product is
+// active right now. Checks keep running and are recorded during a window,
+// but no downtime or degraded incident is opened.
+func (ps *PingService) underMaintenance(productID uint) bool {
+	var windows []MaintenanceWindow
+	if err := ps.db.GetDB().Where("product_id = ?", productID).Find(&windows).Error; err != nil {
+		log.Printf("Failed to load maintenance windows for product %d: %v", productID, err)
+		return false
+	}
+
+	now := time.Now()
+	for _, window := range windows {
+		active, err := window.activeAt(now)
+		if err != nil {
+			log.Printf("Skipping invalid maintenance window %d of product %d: %v", window.ID, productID, err)
+			continue
+		}
+		if active {
+			return true
+		}
+	}
+	return false
+}
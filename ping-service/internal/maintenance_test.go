@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowActiveAt(t *testing.T) {
+	start := mustParseTime(t, "2026-03-04T10:00:00Z")
+	end := mustParseTime(t, "2026-03-04T12:00:00Z")
+
+	tests := []struct {
+		name    string
+		window  MaintenanceWindow
+		at      string
+		want    bool
+		wantErr bool
+	}{
+		{"one-off before", MaintenanceWindow{StartTime: &start, EndTime: &end}, "2026-03-04T09:59:59Z", false, false},
+		{"one-off start", MaintenanceWindow{StartTime: &start, EndTime: &end}, "2026-03-04T10:00:00Z", true, false},
+		{"one-off end", MaintenanceWindow{StartTime: &start, EndTime: &end}, "2026-03-04T12:00:00Z", false, false},
+		{"recurring start", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 60}, "2026-03-04T02:00:00Z", true, false},
+		{"recurring inside", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 60}, "2026-03-04T02:59:59Z", true, false},
+		{"recurring end", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 60}, "2026-03-04T03:00:00Z", false, false},
+		{"recurring before", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 60}, "2026-03-04T01:59:59Z", false, false},
+		{"spans midnight", MaintenanceWindow{Schedule: "0 23 * * 0", DurationMinutes: 180}, "2026-03-09T01:30:00Z", true, false},
+		{"a week long", MaintenanceWindow{Schedule: "0 0 1 * *", DurationMinutes: 7 * 24 * 60}, "2026-03-07T23:59:00Z", true, false},
+		{"timezone", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 30, Timezone: "Europe/Berlin"}, "2026-03-04T01:15:00Z", true, false},
+		{"timezone outside", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 30, Timezone: "Europe/Berlin"}, "2026-03-04T02:15:00Z", false, false},
+		{"invalid schedule", MaintenanceWindow{Schedule: "0 25 * * *", DurationMinutes: 30}, "2026-03-04T01:15:00Z", false, true},
+		{"invalid timezone", MaintenanceWindow{Schedule: "0 2 * * *", DurationMinutes: 30, Timezone: "Mars/Olympus"}, "2026-03-04T01:15:00Z", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.activeAt(mustParseTime(t, tt.at))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("activeAt error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("activeAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowActiveAtMatchesScan(t *testing.T) {
+	window := MaintenanceWindow{Schedule: "*/45 1-3 * * 1,3", DurationMinutes: 50, Timezone: "America/New_York"}
+	schedule, err := parseCron(window.Schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := mustLoadLocation(t, window.Timezone)
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+
+	from := mustParseTime(t, "2026-03-01T00:00:00Z")
+	for at := from; at.Before(from.Add(14 * 24 * time.Hour)); at = at.Add(7 * time.Minute) {
+		want := false
+		for start := at.Truncate(time.Minute); start.After(at.Add(-duration)); start = start.Add(-time.Minute) {
+			if schedule.matches(start.In(location)) {
+				want = true
+				break
+			}
+		}
+		got, err := window.activeAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("activeAt(%v) = %v, want %v", at, got, want)
+		}
+	}
+}
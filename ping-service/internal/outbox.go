@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OutboxBatchSize    = 100
+	OutboxPollInterval = time.Second
+	OutboxMaxBackoff   = time.Minute
+	OutboxRetention    = 7 * 24 * time.Hour
+
+	// outboxLockKey is the advisory lock that lets one replica relay at a time.
+	outboxLockKey = 7_401_001
+)
+
+// enqueueNotification writes the event to the outbox as part of tx, so it
+// is published if and only if tx commits. Every event gets an EventID the
+// notification-service uses to drop redeliveries.
+func enqueueNotification(tx *gorm.DB, event NotificationEvent) error {
+	event.EventID = uuid.NewString()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&NotificationOutbox{
+		EventID:   event.EventID,
+		ProductID: event.ProductID,
+		EventType: event.EventType,
+		Payload:   string(payload),
+	}).Error
+}
+
+// OutboxRelay publishes outbox rows to Kafka in insertion order. Only the
+// replica holding the advisory lock relays a batch, which keeps the order
+// across replicas. A failed batch is retried with backoff and only marked
+// published after Kafka acknowledged it; a crash in between republishes the
+// batch, which consumers deduplicate by EventID.
+type OutboxRelay struct {
+	db       *gorm.DB
+	producer *KafkaProducer
+	wake     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewOutboxRelay(db *gorm.DB, producer *KafkaProducer) *OutboxRelay {
+	return &OutboxRelay{
+		db:       db,
+		producer: producer,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Notify wakes the relay after a transaction with new events committed.
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start relays until ctx is cancelled; Wait blocks until it has stopped.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go r.run(ctx)
+}
+
+func (r *OutboxRelay) Wait() {
+	r.wg.Wait()
+}
+
+func (r *OutboxRelay) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(OutboxPollInterval)
+	defer ticker.Stop()
+
+	backoff := time.Duration(0)
+	lastCleanup := time.Time{}
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-ctx.Done():
+			return
+		}
+
+		if time.Since(lastCleanup) > time.Hour {
+			r.cleanup()
+			lastCleanup = time.Now()
+		}
+
+		for {
+			published, err := r.relayBatch(ctx)
+			if err != nil {
+				backoff = nextOutboxBackoff(backoff)
+				log.Printf("Failed to relay notifications, retrying in %v: %v", backoff, err)
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			backoff = 0
+			if published < OutboxBatchSize {
+				break
+			}
+		}
+	}
+}
+
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	published := 0
+	var publishErr error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var rows []NotificationOutbox
+		if err := tx.Where("published_at IS NULL").
+			Order("id").
+			Limit(OutboxBatchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+
+		if publishErr = r.producer.PublishOutbox(ctx, rows); publishErr != nil {
+			// The rows stay unpublished; only the bookkeeping is committed
+			return tx.Model(&NotificationOutbox{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": publishErr.Error(),
+			}).Error
+		}
+
+		published = len(rows)
+		return tx.Model(&NotificationOutbox{}).Where("id IN ?", ids).
+			Update("published_at", time.Now()).Error
+	})
+
+	if err == nil && publishErr != nil {
+		return 0, publishErr
+	}
+	if err == nil && published > 0 {
+		log.Printf("Relayed %d notifications to Kafka", published)
+	}
+	return published, err
+}
+
+func (r *OutboxRelay) cleanup() {
+	result := r.db.Where("published_at < ?", time.Now().Add(-OutboxRetention)).Delete(&NotificationOutbox{})
+	if result.Error != nil {
+		log.Printf("Failed to clean up published notifications: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Removed %d published notifications from the outbox", result.RowsAffected)
+	}
+}
+
+func nextOutboxBackoff(current time.Duration) time.Duration {
+	if current == 0 {
+		return OutboxPollInterval
+	}
+	if current *= 2; current > OutboxMaxBackoff {
+		return OutboxMaxBackoff
+	}
+	return current
+}
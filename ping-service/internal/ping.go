@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -16,15 +15,31 @@ const (
 	BaseBackoffDelay    = 2 * time.Second
 )
 
+const (
+	IncidentTypeDown     = "down"
+	IncidentTypeDegraded = "degraded"
+	IncidentTypeFlapping = "flapping"
+)
+
 type PingService struct {
 	db            Service
 	heap          *PingHeap
 	workerPool    *WorkerPool
-	httpClient    *http.Client
+	checkers      map[string]Checker
 	ctx           context.Context
 	cancel        context.CancelFunc
-	lastQueried   time.Time
 	kafkaProducer *KafkaProducer
+	outbox        *OutboxRelay
+	certMonitor   *CertMonitor
+	resultWriter  *ResultWriter
+	coordinator   *LeaseCoordinator
+	agentServer   *AgentServer
+	adminServer   *AdminServer
+
+	mode          string
+	location      string
+	probeProducer *KafkaProducer
+	probeConsumer *KafkaConsumer
 }
 
 func NewPingService(db Service, workerCount int) *PingService {
@@ -46,32 +61,114 @@ func NewPingService(db Service, workerCount int) *PingService {
 
 	kafkaProducer := NewKafkaProducer(kafkaBrokers, kafkaTopic)
 
+	mode := os.Getenv("PING_MODE")
+	if mode == "" {
+		mode = ModeStandalone
+	}
+	location := os.Getenv("PING_LOCATION")
+
 	ps := &PingService{
-		db:   db,
-		heap: NewPingHeap(),
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		db:            db,
+		heap:          NewPingHeap(),
+		checkers:      newCheckers(),
 		ctx:           ctx,
 		cancel:        cancel,
-		lastQueried:   time.Now(),
 		kafkaProducer: kafkaProducer,
+		outbox:        NewOutboxRelay(db.GetDB(), kafkaProducer),
+		resultWriter:  NewResultWriter(db.GetDB()),
+		mode:          mode,
+		location:      location,
+	}
+	ps.checkers[CheckTypeHeartbeat] = &heartbeatChecker{db: db.GetDB()}
+
+	ps.workerPool = NewWorkerPool(workerCount, ps.processPing)
+	ps.certMonitor = NewCertMonitor(ps)
+
+	probeTopic := os.Getenv("PROBE_RESULTS_TOPIC")
+	if probeTopic == "" {
+		probeTopic = DefaultProbeTopic
+	}
+	switch mode {
+	case ModeProbe:
+		ps.probeProducer = NewKafkaProducer(kafkaBrokers, probeTopic)
+	case ModeCoordinator:
+		groupID := os.Getenv("PROBE_CONSUMER_GROUP")
+		if groupID == "" {
+			groupID = DefaultProbeConsumerGroup
+		}
+		ps.probeConsumer = NewKafkaConsumer(kafkaBrokers, probeTopic, groupID)
 	}
 
-	ps.workerPool = NewWorkerPool(workerCount, ps)
+	switch coordination := os.Getenv("PING_COORDINATION"); coordination {
+	case "", CoordinationNone:
+	case CoordinationLease:
+		if mode != ModeStandalone {
+			// Leases split products between replicas, while every probe
+			// location has to check every product
+			log.Printf("PING_COORDINATION=%s is only supported in %s mode, ignoring", coordination, ModeStandalone)
+			break
+		}
+		ps.coordinator = NewLeaseCoordinator(db.GetDB(), newInstanceID(), DefaultLeaseTTL, func() {
+			if err := ps.reconcileProducts(); err != nil {
+				log.Printf("Error reconciling products: %v", err)
+			}
+		})
+	default:
+		log.Printf("Unknown PING_COORDINATION %q, running without coordination", coordination)
+	}
+
+	if addr := os.Getenv("AGENT_GRPC_ADDR"); addr != "" {
+		if mode != ModeStandalone {
+			log.Printf("AGENT_GRPC_ADDR is only supported in %s mode, ignoring", ModeStandalone)
+		} else {
+			ps.agentServer = NewAgentServer(ps, addr, os.Getenv("AGENT_TLS_CERT"), os.Getenv("AGENT_TLS_KEY"), os.Getenv("AGENT_GRPC_INSECURE") == "true")
+		}
+	}
+	if addr := os.Getenv("ADMIN_HTTP_ADDR"); addr != "" {
+		ps.adminServer = NewAdminServer(ps, addr, os.Getenv("ADMIN_TOKEN"))
+	}
 	return ps
 }
 
 func (ps *PingService) Start() error {
-	log.Println("Starting ping service...")
+	log.Printf("Starting ping service in %s mode...", ps.mode)
+
+	ps.outbox.Start(ps.ctx)
 
-	if err := ps.loadProducts(); err != nil {
+	if ps.adminServer != nil {
+		if err := ps.adminServer.Start(); err != nil {
+			return err
+		}
+	}
+
+	if ps.mode == ModeCoordinator {
+		go ps.consumeProbeReports()
+		log.Println("Ping service started successfully")
+		return nil
+	}
+
+	if ps.coordinator != nil {
+		// The first sync reconciles products through the change callback
+		if err := ps.coordinator.Sync(ps.ctx); err != nil {
+			return fmt.Errorf("failed to acquire product leases: %w", err)
+		}
+		go ps.coordinator.Run(ps.ctx)
+	} else if err := ps.reconcileProducts(); err != nil {
 		return fmt.Errorf("failed to load products: %w", err)
 	}
 
+	ps.resultWriter.Start()
 	ps.workerPool.Start()
 
-	go ps.scheduler()
+	if ps.agentServer != nil {
+		if err := ps.agentServer.Start(); err != nil {
+			return err
+		}
+	}
+
+	go runScheduler(ps.ctx, ps.heap, ps.workerPool)
+
+	go ps.listenForProductChanges()
 
 	go ps.periodicProductFetcher()
 
@@ -82,134 +179,253 @@ func (ps *PingService) Start() error {
 func (ps *PingService) Stop() {
 	log.Println("Stopping ping service...")
 	ps.cancel()
+	if ps.adminServer != nil {
+		ps.adminServer.Stop()
+	}
+	if ps.agentServer != nil {
+		ps.agentServer.Stop()
+	}
 	ps.workerPool.Stop()
+	ps.resultWriter.Stop()
 
+	if ps.coordinator != nil {
+		ps.coordinator.Release()
+	}
+
+	// Events still in the outbox are relayed after the next start
+	ps.outbox.Wait()
 	if err := ps.kafkaProducer.Close(); err != nil {
 		log.Printf("Error closing Kafka producer: %v", err)
 	}
-
-	log.Println("Ping service stopped")
-}
-
-func (ps *PingService) loadProducts() error {
-	var products []Product
-	if err := ps.db.GetDB().Find(&products).Error; err != nil {
-		return err
+	if ps.probeProducer != nil {
+		if err := ps.probeProducer.Close(); err != nil {
+			log.Printf("Error closing probe report producer: %v", err)
+		}
 	}
-
-	now := time.Now()
-	for _, product := range products {
-		if product.HealthAPI != "" {
-			item := &PingItem{
-				ProductID:  product.ID,
-				HealthAPI:  product.HealthAPI,
-				NextPingAt: now,
-				RetryCount: 0,
-				IsDown:     false,
-			}
-			ps.heap.SafePush(item)
+	if ps.probeConsumer != nil {
+		if err := ps.probeConsumer.Close(); err != nil {
+			log.Printf("Error closing probe report consumer: %v", err)
 		}
 	}
 
-	ps.lastQueried = now
-
-	log.Printf("Loaded %d products for health checking", len(products))
-	return nil
+	log.Println("Ping service stopped")
 }
 
-func (ps *PingService) scheduler() {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+// runScheduler hands every item that is due to the worker pool until ctx is
+// cancelled. In between it sleeps until the earliest check is due, or until
+// the heap reports a new earliest item.
+func runScheduler(ctx context.Context, h *PingHeap, wp *WorkerPool) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
+		if next := processReadyItems(h, wp); next.IsZero() {
+			timer.Stop()
+		} else {
+			timer.Reset(time.Until(next))
+		}
+
 		select {
-		case <-ticker.C:
-			ps.processReadyItems()
-		case <-ps.ctx.Done():
+		case <-timer.C:
+		case <-h.Wake():
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (ps *PingService) processReadyItems() {
-	now := time.Now()
-
+// processReadyItems submits every due item and returns when the next one is
+// due, or zero if the heap is empty.
+func processReadyItems(h *PingHeap, wp *WorkerPool) time.Time {
 	for {
-		item := ps.heap.SafePeek()
-		if item == nil || item.NextPingAt.After(now) {
-			break
+		item, next := h.SafePopDue(time.Now())
+		if item == nil {
+			return next
 		}
+		wp.SubmitJob(item)
+	}
+}
+
+// newPingItem builds the item for a product. An invalid check config is an
+// error rather than a fallback to the defaults: checking a TCP or
+// authenticated product with a plain GET would only raise false alerts.
+func newPingItem(product Product, now time.Time) (*PingItem, error) {
+	config, err := NewCheckSettings(product.CheckConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid check config: %w", err)
+	}
+
+	return &PingItem{
+		ProductID:  product.ID,
+		HealthAPI:  product.HealthAPI,
+		Config:     config,
+		Version:    productVersion(product),
+		NextPingAt: now.Add(config.ScheduleJitter()),
+		RetryCount: 0,
+		IsDown:     false,
+	}, nil
+}
 
-		item = ps.heap.SafePop()
-		ps.workerPool.SubmitJob(item)
+// productVersion is the latest updated_at of the product and its check
+// config.
+func productVersion(product Product) time.Time {
+	version := product.UpdatedAt
+	if product.CheckConfig != nil && product.CheckConfig.UpdatedAt.After(version) {
+		version = product.CheckConfig.UpdatedAt
 	}
+	return version
+}
+
+// owns reports whether this instance is responsible for checking the
+// product. Without coordination every product is owned.
+func (ps *PingService) owns(productID uint) bool {
+	return ps.coordinator == nil || ps.coordinator.Owns(productID)
 }
 
 func (ps *PingService) processPing(item *PingItem) {
-	success := ps.performHealthCheck(item.HealthAPI)
+	checked := item.checked
+	item.checked = nil
 
-	if success {
-		ps.handleSuccessfulPing(item)
-	} else {
-		ps.handleFailedPing(item)
+	if !ps.owns(item.ProductID) {
+		ps.heap.SafeRemove(item.ProductID)
+		if checked != nil {
+			checked <- nil
+		}
+		return
+	}
+
+	checkedAt := time.Now()
+	outcome := runCheck(ps.ctx, ps.checkers, item)
+
+	// The lease may have moved to another replica during the check, which
+	// then owns the product's downtimes; recording a transition here too
+	// would open a second one
+	if !ps.owns(item.ProductID) {
+		log.Printf("Product %d changed owner during its check, result dropped", item.ProductID)
+		ps.heap.SafeRemove(item.ProductID)
+		if checked != nil {
+			checked <- nil
+		}
+		return
+	}
+
+	ps.recordOutcome(item, ps.location, checkedAt, outcome)
+	ps.heap.SafePush(item)
+	if checked != nil {
+		checked <- outcome
 	}
 }
 
-func (ps *PingService) performHealthCheck(healthAPI string) bool {
-	req, err := http.NewRequestWithContext(ps.ctx, "GET", healthAPI, nil)
-	if err != nil {
-		log.Printf("Failed to create request for %s: %v", healthAPI, err)
-		return false
+// recordOutcome stores a check result and applies the state transitions it
+// causes. It also sets item.NextPingAt; rescheduling is up to the caller.
+func (ps *PingService) recordOutcome(item *PingItem, location string, checkedAt time.Time, outcome *CheckOutcome) {
+	if outcome.Skipped {
+		log.Printf("Product %d check skipped: %s", item.ProductID, outcome.Reason)
+		item.scheduleNext()
+		return
 	}
 
-	resp, err := ps.httpClient.Do(req)
-	if err != nil {
-		log.Printf("Failed to ping %s: %v", healthAPI, err)
-		return false
+	ps.resultWriter.Enqueue(item.ProductID, location, checkedAt, outcome)
+
+	if len(outcome.PeerCertificates) > 0 {
+		ps.certMonitor.Observe(item.ProductID, outcome.PeerCertificates)
 	}
-	defer resp.Body.Close()
 
-	return resp.StatusCode == http.StatusOK
+	if outcome.Success {
+		ps.handleSuccessfulPing(item, outcome)
+	} else {
+		ps.handleFailedPing(item, outcome)
+	}
 }
 
-func (ps *PingService) handleSuccessfulPing(item *PingItem) {
+func (ps *PingService) handleSuccessfulPing(item *PingItem, outcome *CheckOutcome) {
 	log.Printf("Product %d health check successful", item.ProductID)
 
-	// If service was down, mark it as up
-	if item.IsDown {
-		ps.markServiceUp(item.ProductID)
+	if ps.mode == ModeProbe {
+		ps.reportToCoordinator(item, true, "")
+	} else {
+		// If service was down, mark it as up
+		if item.IsDown {
+			ps.recordTransition(item)
+			ps.markServiceUp(item.ProductID, !item.IsFlapping)
+		}
+		item.restored = false
+		item.suppressed = false
+
+		ps.trackLatency(item, outcome.Timings.Total)
 	}
 
 	item.RetryCount = 0
 	item.IsDown = false
-	item.NextPingAt = time.Now().Add(DefaultPingInterval)
+	item.LastFailure = ""
+	item.Components = nil
+	item.scheduleNext()
 
-	ps.heap.SafePush(item)
+	if ps.mode != ModeProbe {
+		ps.checkFlapping(item)
+	}
 }
 
-func (ps *PingService) handleFailedPing(item *PingItem) {
+func (ps *PingService) handleFailedPing(item *PingItem, outcome *CheckOutcome) {
 	item.RetryCount++
-	log.Printf("Product %d health check failed (attempt %d/%d)", item.ProductID, item.RetryCount, MaxRetries)
+	item.LastFailure = outcome.Reason
+	item.Components = outcome.Components
+	log.Printf("Product %d health check failed (attempt %d/%d): %s", item.ProductID, item.RetryCount, item.Config.MaxRetries, outcome.Reason)
+	if len(outcome.Components) > 0 {
+		log.Printf("Product %d components: %s", item.ProductID, formatComponents(outcome.Components))
+	}
 
-	if item.RetryCount < MaxRetries {
-		backoffDelay := BaseBackoffDelay * time.Duration(1<<(item.RetryCount-1))
-		item.NextPingAt = time.Now().Add(backoffDelay)
-		ps.heap.SafePush(item)
+	if item.RetryCount < item.Config.MaxRetries {
+		item.NextPingAt = time.Now().Add(item.Config.BackoffDelay(item.RetryCount))
+	} else if ps.mode != ModeProbe && !item.IsDown && ps.underMaintenance(item.ProductID) {
+		// Without IsDown a failure that outlasts the window still opens a
+		// downtime once the window ends
+		log.Printf("Product %d failed %d checks during a maintenance window, no downtime recorded", item.ProductID, item.Config.MaxRetries)
+		item.RetryCount = 0
+		item.scheduleNext()
 	} else {
-		log.Printf("Product %d marked as down after %d failed attempts", item.ProductID, MaxRetries)
+		log.Printf("Product %d marked as down after %d failed attempts", item.ProductID, item.Config.MaxRetries)
 
-		if !item.IsDown {
-			ps.markServiceDown(item.ProductID)
+		if ps.mode == ModeProbe {
+			ps.reportToCoordinator(item, false, item.LastFailure)
+		} else {
+			// A down service supersedes a degraded one
+			if item.IsDegraded {
+				ps.resolveDegraded(item.ProductID)
+				item.IsDegraded = false
+			}
+
+			// A restored downtime goes through markServiceDown once so a
+			// notification that was never sent still goes out. So does a
+			// suppressed one, in case the upstream product recovered first
+			if !item.IsDown || item.restored || item.suppressed {
+				if !item.IsDown {
+					ps.recordTransition(item)
+				}
+				item.suppressed = ps.markServiceDown(item.ProductID, item.LastFailure, item.Components, !item.IsFlapping)
+			}
 		}
+		item.SlowCount = 0
 
 		item.IsDown = true
+		item.restored = false
 		item.RetryCount = 0
-		item.NextPingAt = time.Now().Add(DefaultPingInterval)
-		ps.heap.SafePush(item)
+		item.scheduleNext()
+	}
+
+	if ps.mode != ModeProbe {
+		ps.checkFlapping(item)
 	}
 }
 
-func (ps *PingService) markServiceDown(productID uint) {
+// markServiceDown opens a downtime for the product. Without notify, e.g.
+// while the product is flapping, the downtime is recorded but not announced.
+// components is the health document of the failed check, if any.
+//
+// While a product it depends on is down, the downtime is attributed to that
+// incident and not announced either; markServiceDown then reports true and
+// should be called again on the next confirmed failure.
+func (ps *PingService) markServiceDown(productID uint, reason string, components []ComponentStatus, notify bool) (suppressed bool) {
 	tx := ps.db.GetDB().Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -219,18 +435,31 @@ func (ps *PingService) markServiceDown(productID uint) {
 	}()
 
 	var existingDowntime Downtime
-	err := tx.Where("product_id = ? AND end_time IS NULL", productID).
+	err := tx.Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDown).
 		Order("start_time DESC").
 		First(&existingDowntime).Error
 
 	now := time.Now()
 
 	if err != nil {
+		cause, err := findCauseDowntime(tx, productID)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Failed to look up upstream incidents for product %d: %v", productID, err)
+			return
+		}
+
+		// The notification is queued in the same transaction, so the flag
+		// is only ever true together with a queued event
 		downtime := Downtime{
 			ProductID:          productID,
 			StartTime:          now,
 			Status:             "down",
-			IsNotificationSent: false,
+			IncidentType:       IncidentTypeDown,
+			IsNotificationSent: notify && cause == nil,
+			FailureReason:      reason,
+			Components:         components,
+			CauseDowntimeID:    cause,
 		}
 
 		if err := tx.Create(&downtime).Error; err != nil {
@@ -239,6 +468,36 @@ func (ps *PingService) markServiceDown(productID uint) {
 			return
 		}
 
+		root := downtime.ID
+		if cause != nil {
+			root = *cause
+		}
+		if err := linkDependentDowntimes(tx, productID, root); err != nil {
+			tx.Rollback()
+			log.Printf("Failed to link dependent downtimes of product %d: %v", productID, err)
+			return
+		}
+
+		if !notify || cause != nil {
+			if err := tx.Commit().Error; err != nil {
+				log.Printf("Failed to commit downtime transaction for product %d: %v", productID, err)
+				return
+			}
+			if cause != nil {
+				log.Printf("Recorded downtime for product %d without notification, attributed to upstream incident %d", productID, *cause)
+				return true
+			}
+			log.Printf("Recorded downtime for product %d without notification", productID)
+			return
+		}
+
+		dependents, err := dependentNames(tx, productID)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Failed to get dependents of product %d: %v", productID, err)
+			return
+		}
+
 		var product Product
 		if err := tx.Preload("User").First(&product, productID).Error; err != nil {
 			tx.Rollback()
@@ -246,32 +505,48 @@ func (ps *PingService) markServiceDown(productID uint) {
 			return
 		}
 
-		if err := ps.kafkaProducer.SendNotification(ps.ctx, NotificationEvent{
-			ProductID: productID,
-			UserEmail: product.User.Email,
-			Timestamp: now,
-			EventType: "service_down",
-			Message:   fmt.Sprintf("Service %s is down", product.Name),
+		if err := enqueueNotification(tx, NotificationEvent{
+			ProductID:  productID,
+			UserEmail:  product.User.Email,
+			Timestamp:  now,
+			EventType:  "service_down",
+			Message:    fmt.Sprintf("Service %s is down", product.Name),
+			Reason:     reason,
+			Components: components,
+			Dependents: dependents,
 		}); err != nil {
-			log.Printf("Failed to send downtime notification for product %d: %v", productID, err)
-		} else {
-			log.Printf("Downtime notification sent for product %d", productID)
-			downtime.IsNotificationSent = true
-			if err := tx.Save(&downtime).Error; err != nil {
-				tx.Rollback()
-				log.Printf("Failed to update notification status for product %d: %v", productID, err)
-				return
-			}
+			tx.Rollback()
+			log.Printf("Failed to queue downtime notification for product %d: %v", productID, err)
+			return
 		}
 
 		if err := tx.Commit().Error; err != nil {
 			log.Printf("Failed to commit downtime transaction for product %d: %v", productID, err)
 			return
 		}
+		ps.outbox.Notify()
 
 		log.Printf("Recorded downtime for product %d", productID)
 	} else {
-		if !existingDowntime.IsNotificationSent {
+		if notify && !existingDowntime.IsNotificationSent {
+			// A suppressed downtime is announced once its cause has
+			// recovered and the product is still down
+			if existingDowntime.CauseDowntimeID != nil {
+				open, err := isDowntimeOpen(tx, *existingDowntime.CauseDowntimeID)
+				if err != nil || open {
+					tx.Rollback()
+					if err != nil {
+						log.Printf("Failed to check upstream incident of product %d: %v", productID, err)
+					}
+					return true
+				}
+			}
+
+			if existingDowntime.FailureReason == "" {
+				existingDowntime.FailureReason = reason
+				existingDowntime.Components = components
+			}
+
 			var product Product
 			if err := tx.Preload("User").First(&product, productID).Error; err != nil {
 				tx.Rollback()
@@ -279,15 +554,25 @@ func (ps *PingService) markServiceDown(productID uint) {
 				return
 			}
 
-			if err := ps.kafkaProducer.SendNotification(ps.ctx, NotificationEvent{
-				ProductID: productID,
-				UserEmail: product.User.Email,
-				Timestamp: now,
-				EventType: "service_down",
-				Message:   fmt.Sprintf("Service %s is down", product.Name),
+			dependents, err := dependentNames(tx, productID)
+			if err != nil {
+				tx.Rollback()
+				log.Printf("Failed to get dependents of product %d: %v", productID, err)
+				return
+			}
+
+			if err := enqueueNotification(tx, NotificationEvent{
+				ProductID:  productID,
+				UserEmail:  product.User.Email,
+				Timestamp:  now,
+				EventType:  "service_down",
+				Message:    fmt.Sprintf("Service %s is down", product.Name),
+				Reason:     existingDowntime.FailureReason,
+				Components: existingDowntime.Components,
+				Dependents: dependents,
 			}); err != nil {
 				tx.Rollback()
-				log.Printf("Failed to send downtime notification for product %d: %v", productID, err)
+				log.Printf("Failed to queue downtime notification for product %d: %v", productID, err)
 				return
 			}
 
@@ -302,16 +587,20 @@ func (ps *PingService) markServiceDown(productID uint) {
 				log.Printf("Failed to commit notification update transaction for product %d: %v", productID, err)
 				return
 			}
+			ps.outbox.Notify()
 
-			log.Printf("Downtime notification sent for product %d", productID)
+			log.Printf("Downtime notification queued for product %d", productID)
 		} else {
 			tx.Rollback()
-			log.Printf("Product %d still down, notification already sent", productID)
+			log.Printf("Product %d still down, no notification to send", productID)
 		}
 	}
+	return
 }
 
-func (ps *PingService) markServiceUp(productID uint) {
+// markServiceUp closes the open downtime of the product, announcing the
+// recovery only if notify is set.
+func (ps *PingService) markServiceUp(productID uint, notify bool) {
 	tx := ps.db.GetDB().Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -323,7 +612,7 @@ func (ps *PingService) markServiceUp(productID uint) {
 	now := time.Now()
 
 	var downtime Downtime
-	err := tx.Where("product_id = ? AND end_time IS NULL", productID).
+	err := tx.Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDown).
 		Order("start_time DESC").
 		First(&downtime).Error
 
@@ -344,27 +633,38 @@ func (ps *PingService) markServiceUp(productID uint) {
 		return
 	}
 
+	// Nobody was told about a downtime folded into its upstream incident
+	if !notify || (downtime.CauseDowntimeID != nil && !downtime.IsNotificationSent) {
+		if err := tx.Commit().Error; err != nil {
+			log.Printf("Failed to commit recovery transaction for product %d: %v", productID, err)
+			return
+		}
+		log.Printf("Service %d is back up without notification, downtime duration: %v", productID, downtimeDuration)
+		return
+	}
+
 	var product Product
 	if err := tx.Preload("User").First(&product, productID).Error; err != nil {
 		log.Printf("Failed to get product and user info for recovery notification for product %d: %v", productID, err)
 	}
 
-	if err := ps.kafkaProducer.SendNotification(ps.ctx, NotificationEvent{
+	if err := enqueueNotification(tx, NotificationEvent{
 		ProductID: productID,
 		UserEmail: product.User.Email,
 		Timestamp: now,
 		EventType: "service_up",
 		Message:   fmt.Sprintf("Service %s is back up after %v downtime", product.Name, downtimeDuration),
 	}); err != nil {
-		log.Printf("Failed to send recovery notification for product %d: %v", productID, err)
-	} else {
-		log.Printf("Recovery notification sent for product %d", productID)
+		tx.Rollback()
+		log.Printf("Failed to queue recovery notification for product %d: %v", productID, err)
+		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		log.Printf("Failed to commit recovery transaction for product %d: %v", productID, err)
 		return
 	}
+	ps.outbox.Notify()
 
 	log.Printf("Service %d is back up, downtime duration: %v", productID, downtimeDuration)
 }
@@ -378,60 +678,12 @@ func (ps *PingService) periodicProductFetcher() {
 	for {
 		select {
 		case <-ticker.C:
-			ps.fetchNewProducts()
+			if err := ps.reconcileProducts(); err != nil {
+				log.Printf("Error reconciling products: %v", err)
+			}
 		case <-ps.ctx.Done():
 			log.Println("Periodic product fetcher stopped")
 			return
 		}
 	}
 }
-
-func (ps *PingService) fetchNewProducts() {
-	var newProducts []Product
-
-	err := ps.db.GetDB().Where("created_at > ? AND health_api != ''", ps.lastQueried).
-		Find(&newProducts).Error
-
-	if err != nil {
-		log.Printf("Error fetching new products: %v", err)
-		return
-	}
-
-	if len(newProducts) == 0 {
-		log.Println("No new products found")
-		ps.lastQueried = time.Now()
-		return
-	}
-
-	log.Printf("Found %d new products to add to ping queue", len(newProducts))
-
-	now := time.Now()
-	for _, product := range newProducts {
-		if !ps.isProductInHeap(product.ID) {
-			item := &PingItem{
-				ProductID:  product.ID,
-				HealthAPI:  product.HealthAPI,
-				NextPingAt: now,
-				RetryCount: 0,
-				IsDown:     false,
-			}
-			ps.heap.SafePush(item)
-			log.Printf("Added new product to ping queue: ID=%d, Name=%s, HealthAPI=%s",
-				product.ID, product.Name, product.HealthAPI)
-		}
-	}
-
-	ps.lastQueried = now
-}
-
-func (ps *PingService) isProductInHeap(productID uint) bool {
-	ps.heap.mutex.RLock()
-	defer ps.heap.mutex.RUnlock()
-
-	for _, item := range ps.heap.items {
-		if item.ProductID == productID {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// checkerFunc adapts a function to the Checker interface.
+type checkerFunc func(ctx context.Context, item *PingItem) *CheckOutcome
+
+func (f checkerFunc) Check(ctx context.Context, item *PingItem) *CheckOutcome {
+	return f(ctx, item)
+}
+
+func TestProcessPingDropsResultAfterLosingLease(t *testing.T) {
+	coordinator := NewLeaseCoordinator(nil, "test", DefaultLeaseTTL, nil)
+	coordinator.owned = map[uint]bool{1: true}
+	coordinator.validUntil = time.Now().Add(time.Hour)
+
+	ps := &PingService{
+		heap:        NewPingHeap(),
+		coordinator: coordinator,
+		ctx:         context.Background(),
+		checkers: map[string]Checker{
+			CheckTypeHTTP: checkerFunc(func(context.Context, *PingItem) *CheckOutcome {
+				// Another replica takes the product over mid-check
+				coordinator.mutex.Lock()
+				delete(coordinator.owned, 1)
+				coordinator.mutex.Unlock()
+				return failedOutcome("connection refused")
+			}),
+		},
+	}
+	item := newTestItem(1, time.Now())
+	item.Config.MaxRetries = 1
+	ps.heap.SafeAdd(item)
+	popped, _ := ps.heap.SafePopDue(time.Now())
+
+	// Recording the failure would need the database and the result writer
+	checked := make(chan *CheckOutcome, 1)
+	popped.checked = checked
+	ps.processPing(popped)
+
+	if outcome := <-checked; outcome != nil {
+		t.Fatalf("processPing reported %+v, want no result", outcome)
+	}
+	if popped.IsDown || ps.heap.SafeContains(1) {
+		t.Fatalf("product 1 is still tracked (down: %v)", popped.IsDown)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
 )
 
 type WorkerPool struct {
@@ -12,17 +13,29 @@ type WorkerPool struct {
 	wg          sync.WaitGroup
 	ctx         context.Context
 	cancel      context.CancelFunc
-	pingService *PingService
+	handler     func(*PingItem)
+	busy        atomic.Int32
 }
 
-func NewWorkerPool(workerCount int, pingService *PingService) *WorkerPool {
+// WorkerPoolStats shows how saturated the pool is. Queued jobs wait for a
+// free worker; once the queue is full the scheduler blocks.
+type WorkerPoolStats struct {
+	Workers       int `json:"workers"`
+	Busy          int `json:"busy"`
+	Queued        int `json:"queued"`
+	QueueCapacity int `json:"queue_capacity"`
+}
+
+// NewWorkerPool runs handler for every submitted item on workerCount
+// goroutines.
+func NewWorkerPool(workerCount int, handler func(*PingItem)) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &WorkerPool{
 		workerCount: workerCount,
 		jobChan:     make(chan *PingItem, workerCount*2), // buffered channel
 		ctx:         ctx,
 		cancel:      cancel,
-		pingService: pingService,
+		handler:     handler,
 	}
 }
 
@@ -37,18 +50,34 @@ func (wp *WorkerPool) Start() {
 
 func (wp *WorkerPool) Stop() {
 	log.Println("Stopping worker pool...")
+	// jobChan is never closed: SubmitJob may still be sending from another
+	// goroutine, and workers exit on the cancelled context instead.
 	wp.cancel()
-	close(wp.jobChan)
 	wp.wg.Wait()
 	log.Println("Worker pool stopped")
 }
 
-func (wp *WorkerPool) SubmitJob(item *PingItem) {
+func (wp *WorkerPool) Stats() WorkerPoolStats {
+	return WorkerPoolStats{
+		Workers:       wp.workerCount,
+		Busy:          int(wp.busy.Load()),
+		Queued:        len(wp.jobChan),
+		QueueCapacity: cap(wp.jobChan),
+	}
+}
+
+// SubmitJob queues item for a worker. It reports false if the pool shut
+// down before the item was queued.
+func (wp *WorkerPool) SubmitJob(item *PingItem) bool {
+	if wp.ctx.Err() != nil {
+		return false
+	}
 	select {
 	case wp.jobChan <- item:
+		return true
 	case <-wp.ctx.Done():
 		// Pool is shutting down
-		return
+		return false
 	}
 }
 
@@ -58,14 +87,11 @@ func (wp *WorkerPool) worker(id int) {
 
 	for {
 		select {
-		case job, ok := <-wp.jobChan:
-			if !ok {
-				log.Printf("Worker %d: job channel closed", id)
-				return
-			}
-
+		case job := <-wp.jobChan:
 			log.Printf("Worker %d: processing ping for product %d", id, job.ProductID)
-			wp.pingService.processPing(job)
+			wp.busy.Add(1)
+			wp.handler(job)
+			wp.busy.Add(-1)
 
 		case <-wp.ctx.Done():
 			log.Printf("Worker %d: context cancelled", id)
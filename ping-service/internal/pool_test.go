@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWorkerPoolStopWhileSubmitting(t *testing.T) {
+	pool := NewWorkerPool(2, func(*PingItem) {})
+	pool.Start()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Sending on a closed channel would panic here
+			for pool.SubmitJob(&PingItem{}) {
+			}
+		}()
+	}
+	pool.Stop()
+	wg.Wait()
+
+	if pool.SubmitJob(&PingItem{}) {
+		t.Fatal("SubmitJob accepted an item after Stop")
+	}
+}
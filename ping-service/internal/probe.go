@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ModeStandalone checks every product and manages downtimes itself.
+	ModeStandalone = "standalone"
+	// ModeProbe checks products from PING_LOCATION and reports verdicts to
+	// the coordinator instead of opening downtimes.
+	ModeProbe = "probe"
+	// ModeCoordinator runs no checks; it opens and closes downtimes once a
+	// quorum of probe locations agree.
+	ModeCoordinator = "coordinator"
+
+	DefaultProbeTopic         = "probe-results"
+	DefaultProbeConsumerGroup = "ping-coordinator"
+
+	// MinLocationStaleness bounds how quickly a silent location stops
+	// counting towards quorum.
+	MinLocationStaleness = time.Minute
+	// ProbeKeepaliveInterval is how often a probe repeats an unchanged
+	// verdict, well within MinLocationStaleness so the location keeps
+	// counting towards quorum.
+	ProbeKeepaliveInterval = MinLocationStaleness / 2
+)
+
+// needsReport reports whether a verdict has to be sent to the coordinator:
+// the first one, a changed one, or an unchanged one due for a keepalive.
+func (item *PingItem) needsReport(success bool, now time.Time) bool {
+	return item.reportedAt.IsZero() || item.reportedUp != success ||
+		now.Sub(item.reportedAt) >= ProbeKeepaliveInterval
+}
+
+// reportToCoordinator publishes the verdict of a probe location once its
+// local retries have settled. Unchanged verdicts are only repeated every
+// ProbeKeepaliveInterval.
+func (ps *PingService) reportToCoordinator(item *PingItem, success bool, reason string) {
+	now := time.Now()
+	if !item.needsReport(success, now) {
+		return
+	}
+	report := ProbeReport{
+		ProductID: item.ProductID,
+		Location:  ps.location,
+		Success:   success,
+		Reason:    reason,
+		CheckedAt: now,
+	}
+	if err := ps.probeProducer.SendProbeReport(ps.ctx, report); err != nil {
+		log.Printf("Failed to report product %d to coordinator: %v", item.ProductID, err)
+		return
+	}
+	item.reportedAt = now
+	item.reportedUp = success
+}
+
+func (ps *PingService) consumeProbeReports() {
+	log.Println("Consuming probe reports...")
+	if err := ps.probeConsumer.ConsumeProbeReports(ps.ctx, ps.handleProbeReport); err != nil && ps.ctx.Err() == nil {
+		log.Printf("Probe report consumer stopped: %v", err)
+	}
+}
+
+func (ps *PingService) handleProbeReport(report ProbeReport) {
+	if report.ProductID == 0 || report.Location == "" {
+		log.Printf("Ignoring probe report without product or location: %+v", report)
+		return
+	}
+
+	db := ps.db.GetDB()
+	status := LocationStatus{
+		ProductID: report.ProductID,
+		Location:  report.Location,
+		Success:   report.Success,
+		Reason:    report.Reason,
+		CheckedAt: report.CheckedAt,
+	}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&status).Error; err != nil {
+		log.Printf("Failed to store probe report for product %d from %s: %v", report.ProductID, report.Location, err)
+		return
+	}
+
+	ps.evaluateQuorum(report.ProductID)
+}
+
+// evaluateQuorum opens a downtime when at least Quorum locations with a
+// fresh report see the product down, and closes it once fewer do.
+func (ps *PingService) evaluateQuorum(productID uint) {
+	db := ps.db.GetDB()
+
+	var product Product
+	if err := db.Preload("CheckConfig").First(&product, productID).Error; err != nil {
+		log.Printf("Failed to load product %d for quorum evaluation: %v", productID, err)
+		return
+	}
+	settings, err := NewCheckSettings(product.CheckConfig)
+	if err != nil {
+		log.Printf("Skipping quorum evaluation for product %d: invalid check config: %v", productID, err)
+		return
+	}
+
+	staleness := 3 * settings.Interval
+	if staleness < MinLocationStaleness {
+		staleness = MinLocationStaleness
+	}
+
+	var statuses []LocationStatus
+	if err := db.Where("product_id = ? AND checked_at > ?", productID, time.Now().Add(-staleness)).
+		Order("location").
+		Find(&statuses).Error; err != nil {
+		log.Printf("Failed to load location statuses for product %d: %v", productID, err)
+		return
+	}
+
+	var failing []LocationStatus
+	for _, status := range statuses {
+		if !status.Success {
+			failing = append(failing, status)
+		}
+	}
+
+	var open Downtime
+	hasOpen := db.Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDown).
+		First(&open).Error == nil
+
+	switch {
+	case len(failing) >= settings.Quorum:
+		if !hasOpen && ps.underMaintenance(productID) {
+			log.Printf("Product %d down from %d of %d locations during a maintenance window, no downtime recorded",
+				productID, len(failing), len(statuses))
+			return
+		}
+		if !hasOpen || !open.IsNotificationSent {
+			log.Printf("Product %d down from %d of %d locations (quorum %d)",
+				productID, len(failing), len(statuses), settings.Quorum)
+			ps.markServiceDown(productID, quorumReason(failing, len(statuses)), nil, true)
+		}
+	case hasOpen:
+		log.Printf("Product %d down from %d of %d locations, below quorum %d",
+			productID, len(failing), len(statuses), settings.Quorum)
+		ps.recordLocationResults(open.ID, statuses)
+		ps.markServiceUp(productID, true)
+		return
+	}
+
+	if err := db.Where("product_id = ? AND incident_type = ? AND end_time IS NULL", productID, IncidentTypeDown).
+		First(&open).Error; err == nil {
+		ps.recordLocationResults(open.ID, statuses)
+	}
+}
+
+// recordLocationResults keeps the per-location view on the open downtime.
+func (ps *PingService) recordLocationResults(downtimeID uint, statuses []LocationStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	results := make([]DowntimeLocationResult, len(statuses))
+	for i, status := range statuses {
+		results[i] = DowntimeLocationResult{
+			DowntimeID: downtimeID,
+			Location:   status.Location,
+			Success:    status.Success,
+			Reason:     status.Reason,
+			CheckedAt:  status.CheckedAt,
+		}
+	}
+
+	if err := ps.db.GetDB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "downtime_id"}, {Name: "location"}},
+		DoUpdates: clause.AssignmentColumns([]string{"success", "reason", "checked_at"}),
+	}).Create(&results).Error; err != nil {
+		log.Printf("Failed to record location results for downtime %d: %v", downtimeID, err)
+	}
+}
+
+func quorumReason(failing []LocationStatus, total int) string {
+	sort.Slice(failing, func(i, j int) bool { return failing[i].Location < failing[j].Location })
+
+	parts := make([]string, len(failing))
+	for i, status := range failing {
+		parts[i] = fmt.Sprintf("%s: %s", status.Location, status.Reason)
+	}
+	return fmt.Sprintf("down from %d of %d locations (%s)", len(failing), total, strings.Join(parts, "; "))
+}
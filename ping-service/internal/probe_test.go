@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNeedsReport(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		reportedAt time.Time
+		reportedUp bool
+		success    bool
+		want       bool
+	}{
+		{name: "first success", success: true, want: true},
+		{name: "first failure", success: false, want: true},
+		{name: "unchanged success", reportedAt: now.Add(-time.Second), reportedUp: true, success: true},
+		{name: "unchanged failure", reportedAt: now.Add(-time.Second), success: false},
+		{name: "went down", reportedAt: now.Add(-time.Second), reportedUp: true, success: false, want: true},
+		{name: "came back up", reportedAt: now.Add(-time.Second), success: true, want: true},
+		{name: "keepalive due", reportedAt: now.Add(-ProbeKeepaliveInterval), reportedUp: true, success: true, want: true},
+		{name: "keepalive not yet due", reportedAt: now.Add(-ProbeKeepaliveInterval + time.Second), reportedUp: true, success: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &PingItem{reportedAt: tt.reportedAt, reportedUp: tt.reportedUp}
+			if got := item.needsReport(tt.success, now); got != tt.want {
+				t.Fatalf("needsReport(%v) = %v, want %v", tt.success, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// ProductChangesChannel is the Postgres NOTIFY channel the HTTP service
+// publishes product and check config changes on.
+const ProductChangesChannel = "product_changes"
+
+const productListenRetryDelay = 5 * time.Second
+
+// checkedProducts selects the products there is something to check for: a
+// health API, a heartbeat or a synthetic check.
+const checkedProducts = "(health_api != '' OR id IN (SELECT product_id FROM check_configs WHERE check_type IN ('heartbeat', 'synthetic')))"
+
+// scheduledProducts selects the products the ping-service checks itself,
+// those no agent is assigned to.
+const scheduledProducts = checkedProducts + " AND agent_id IS NULL"
+
+type ProductChange struct {
+	ProductID uint   `json:"product_id"`
+	Action    string `json:"action"` // "upsert", "delete", "heartbeat"
+}
+
+// reconcileProducts brings the heap in line with the products table: new
+// products and newly enabled checks are added, changed ones are replaced
+// and deleted or disabled ones are dropped. Products assigned to a remote
+// agent are checked by that agent instead.
+func (ps *PingService) reconcileProducts() error {
+	var products []Product
+	if err := ps.db.GetDB().Scopes(withCheckConfig).
+		Where(scheduledProducts).
+		Find(&products).Error; err != nil {
+		return err
+	}
+
+	versions := ps.heap.SafeVersions()
+	now := time.Now()
+	var added, updated, removed int
+
+	var incidents map[uint]openIncidents
+	for _, product := range products {
+		if !ps.owns(product.ID) {
+			continue
+		}
+
+		// Products with an invalid config stay in versions and are
+		// dropped below until the config is fixed.
+		item, err := newPingItem(product, now)
+		if err != nil {
+			log.Printf("Not scheduling product %d: %v", product.ID, err)
+			continue
+		}
+		version, tracked := versions[product.ID]
+		delete(versions, product.ID)
+
+		switch {
+		case !tracked:
+			if incidents == nil {
+				var err error
+				if incidents, err = ps.loadOpenIncidents(); err != nil {
+					return err
+				}
+			}
+			restoreState(item, incidents[product.ID])
+			if ps.heap.SafeAdd(item) {
+				added++
+			}
+		case !version.Equal(item.Version):
+			ps.heap.SafeUpsert(item)
+			updated++
+		}
+	}
+
+	for productID := range versions {
+		if ps.heap.SafeRemove(productID) {
+			removed++
+		}
+	}
+
+	log.Printf("Reconciled %d products for health checking (%d added, %d updated, %d removed)",
+		len(products), added, updated, removed)
+	return nil
+}
+
+type openIncidents struct {
+	down     bool
+	degraded bool
+	flapping bool
+}
+
+// loadOpenIncidents returns the products with an open downtime, degraded or
+// flapping incident, so items created after a restart continue where the previous
+// process left off.
+func (ps *PingService) loadOpenIncidents() (map[uint]openIncidents, error) {
+	var rows []struct {
+		ProductID    uint
+		IncidentType string
+	}
+	if err := ps.db.GetDB().Model(&Downtime{}).
+		Select("DISTINCT product_id, incident_type").
+		Where("end_time IS NULL").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	incidents := make(map[uint]openIncidents, len(rows))
+	for _, row := range rows {
+		open := incidents[row.ProductID]
+		switch row.IncidentType {
+		case IncidentTypeDown:
+			open.down = true
+		case IncidentTypeDegraded:
+			open.degraded = true
+		case IncidentTypeFlapping:
+			open.flapping = true
+		}
+		incidents[row.ProductID] = open
+	}
+	return incidents, nil
+}
+
+// restoreState marks an item as already down, degraded or flapping. The first check
+// then either closes the incident or confirms it without starting a fresh
+// round of retries.
+func restoreState(item *PingItem, open openIncidents) {
+	if open.down {
+		item.IsDown = true
+		item.RetryCount = item.Config.MaxRetries - 1
+		item.restored = true
+		log.Printf("Restored open downtime for product %d", item.ProductID)
+	}
+	if open.degraded {
+		item.IsDegraded = true
+		item.SlowCount = item.Config.DegradedAfter
+		log.Printf("Restored open degraded incident for product %d", item.ProductID)
+	}
+	if open.flapping {
+		// The transition history is lost; flapping ends after one quiet window
+		item.IsFlapping = true
+		item.Transitions = []time.Time{time.Now()}
+		log.Printf("Restored open flapping incident for product %d", item.ProductID)
+	}
+}
+
+// reloadProduct applies a single product change to the heap.
+func (ps *PingService) reloadProduct(productID uint) {
+	var product Product
+	err := ps.db.GetDB().Scopes(withCheckConfig).First(&product, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && (!isScheduled(product) || !ps.owns(productID))) {
+		if ps.heap.SafeRemove(productID) {
+			log.Printf("Removed product %d from ping queue", productID)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to reload product %d: %v", productID, err)
+		return
+	}
+
+	item, err := newPingItem(product, time.Now())
+	if err != nil {
+		log.Printf("Not scheduling product %d: %v", productID, err)
+		ps.heap.SafeRemove(productID)
+		return
+	}
+	ps.heap.SafeUpsert(item)
+	log.Printf("Reloaded product %d: HealthAPI=%s", productID, product.HealthAPI)
+}
+
+// withCheckConfig preloads a product's check config with everything the
+// checkers need.
+func withCheckConfig(db *gorm.DB) *gorm.DB {
+	return db.Preload("CheckConfig.Assertions").Preload("CheckConfig.Headers").
+		Preload("CheckConfig.Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		})
+}
+
+// isScheduled is the in-memory counterpart of scheduledProducts.
+func isScheduled(product Product) bool {
+	return isChecked(product) && product.AgentID == nil
+}
+
+// isChecked is the in-memory counterpart of checkedProducts.
+func isChecked(product Product) bool {
+	if product.CheckConfig != nil {
+		switch product.CheckConfig.CheckType {
+		case CheckTypeHeartbeat, CheckTypeSynthetic:
+			return true
+		}
+	}
+	return product.HealthAPI != ""
+}
+
+// listenForProductChanges applies product changes as the HTTP service
+// announces them. The periodic reconcile remains the fallback, and a full
+// reconcile runs after every reconnect to catch changes missed meanwhile.
+func (ps *PingService) listenForProductChanges() {
+	for {
+		err := ps.listenOnce()
+		if ps.ctx.Err() != nil {
+			return
+		}
+		log.Printf("Product change listener disconnected: %v", err)
+
+		select {
+		case <-time.After(productListenRetryDelay):
+		case <-ps.ctx.Done():
+			return
+		}
+
+		if err := ps.reconcileProducts(); err != nil {
+			log.Printf("Error reconciling products: %v", err)
+		}
+	}
+}
+
+func (ps *PingService) listenOnce() error {
+	conn, err := ps.db.GetSQLDB().Conn(ps.ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		if _, err := pgConn.Exec(ps.ctx, "LISTEN "+ProductChangesChannel); err != nil {
+			return err
+		}
+		log.Printf("Listening for product changes on %q", ProductChangesChannel)
+
+		for {
+			notification, err := pgConn.WaitForNotification(ps.ctx)
+			if err != nil {
+				return err
+			}
+
+			var change ProductChange
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil || change.ProductID == 0 {
+				log.Printf("Ignoring malformed product change %q", notification.Payload)
+				continue
+			}
+
+			switch change.Action {
+			case "delete":
+				if ps.heap.SafeRemove(change.ProductID) {
+					log.Printf("Removed product %d from ping queue", change.ProductID)
+				}
+				continue
+			case "heartbeat":
+				// Check right away so a heartbeat closes an open downtime
+				// without waiting for the next interval
+				ps.heap.SafeCheckNow(change.ProductID)
+				continue
+			}
+			ps.reloadProduct(change.ProductID)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ResultBatchSize     = 500
+	ResultFlushInterval = 2 * time.Second
+	ResultBufferSize    = 10000
+)
+
+// ResultWriter batches check results from the workers into check_results.
+// Enqueue never blocks a worker: when the buffer is full the result is
+// dropped and counted.
+type ResultWriter struct {
+	db      *gorm.DB
+	results chan CheckResult
+	wg      sync.WaitGroup
+	dropped int
+	last    map[uint]CheckResult // latest result per product, written or not
+	mutex   sync.Mutex
+}
+
+func NewResultWriter(db *gorm.DB) *ResultWriter {
+	return &ResultWriter{
+		db:      db,
+		results: make(chan CheckResult, ResultBufferSize),
+		last:    make(map[uint]CheckResult),
+	}
+}
+
+func (rw *ResultWriter) Start() {
+	rw.wg.Add(1)
+	go rw.run()
+}
+
+// Stop flushes whatever is buffered. It must only be called once no worker
+// can call Enqueue anymore.
+func (rw *ResultWriter) Stop() {
+	close(rw.results)
+	rw.wg.Wait()
+}
+
+// Enqueue records a check run from location, which is empty for the
+// ping-service's own checks outside probe mode.
+func (rw *ResultWriter) Enqueue(productID uint, location string, at time.Time, outcome *CheckOutcome) {
+	result := CheckResult{
+		ProductID:  productID,
+		Timestamp:  at,
+		Success:    outcome.Success,
+		StatusCode: outcome.StatusCode,
+		DNSMs:      durationMs(outcome.Timings.DNS),
+		ConnectMs:  durationMs(outcome.Timings.Connect),
+		TLSMs:      durationMs(outcome.Timings.TLS),
+		TTFBMs:     durationMs(outcome.Timings.TTFB),
+		TotalMs:    durationMs(outcome.Timings.Total),
+		Location:   location,
+		Error:      outcome.Reason,
+		Components: outcome.Components,
+	}
+
+	rw.mutex.Lock()
+	rw.last[productID] = result
+	rw.mutex.Unlock()
+
+	select {
+	case rw.results <- result:
+	default:
+		rw.mutex.Lock()
+		rw.dropped++
+		rw.mutex.Unlock()
+	}
+}
+
+// Last returns the latest result recorded for a product since start.
+func (rw *ResultWriter) Last(productID uint) (CheckResult, bool) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	result, ok := rw.last[productID]
+	return result, ok
+}
+
+func (rw *ResultWriter) run() {
+	defer rw.wg.Done()
+
+	ticker := time.NewTicker(ResultFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]CheckResult, 0, ResultBatchSize)
+	for {
+		select {
+		case result, ok := <-rw.results:
+			if !ok {
+				rw.flush(batch)
+				return
+			}
+			batch = append(batch, result)
+			if len(batch) >= ResultBatchSize {
+				rw.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			rw.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (rw *ResultWriter) flush(batch []CheckResult) {
+	rw.mutex.Lock()
+	dropped := rw.dropped
+	rw.dropped = 0
+	rw.mutex.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Dropped %d check results because the write buffer was full", dropped)
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := rw.db.CreateInBatches(batch, ResultBatchSize).Error; err != nil {
+		log.Printf("Failed to write %d check results: %v", len(batch), err)
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
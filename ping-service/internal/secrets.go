@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CheckSecretsKeyEnv holds the base64 encoded AES-256 key check credentials
+// are encrypted with. The HTTP service and the ping-service share the key.
+const CheckSecretsKeyEnv = "CHECK_SECRETS_KEY"
+
+func secretsCipher() (cipher.AEAD, error) {
+	encoded := os.Getenv(CheckSecretsKeyEnv)
+	if encoded == "" {
+		return nil, errors.New(CheckSecretsKeyEnv + " is not configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must be a base64 encoded 32 byte key", CheckSecretsKeyEnv)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptSecret opens a secret sealed by encryptSecret in the HTTP service
+// (http/internal/service/secrets.go): AES-GCM with the random nonce in front
+// of the ciphertext, base64 encoded. The ping-service only decrypts, but
+// both copies must agree on the format; secrets_test.go in both modules
+// decrypts the same sample to catch drift.
+func decryptSecret(encoded string) (string, error) {
+	aead, err := secretsCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
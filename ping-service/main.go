@@ -30,6 +30,17 @@ func validateEnvironment() error {
 		return fmt.Errorf("missing required environment variables: %s", strings.Join(missingVars, ", "))
 	}
 
+	switch mode := os.Getenv("PING_MODE"); mode {
+	case "", internal.ModeStandalone, internal.ModeCoordinator:
+	case internal.ModeProbe:
+		if os.Getenv("PING_LOCATION") == "" {
+			return fmt.Errorf("PING_LOCATION is required when PING_MODE=%s", mode)
+		}
+	default:
+		return fmt.Errorf("invalid PING_MODE %q: expected %s, %s or %s",
+			mode, internal.ModeStandalone, internal.ModeProbe, internal.ModeCoordinator)
+	}
+
 	// Validate specific formats
 	if kafkaBrokers := os.Getenv("KAFKA_BROKERS"); kafkaBrokers != "" {
 		brokers := strings.Split(kafkaBrokers, ",")
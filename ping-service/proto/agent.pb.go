@@ -0,0 +1,444 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: agent.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetAssignmentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentVersion  string                 `protobuf:"bytes,1,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAssignmentsRequest) Reset() {
+	*x = GetAssignmentsRequest{}
+	mi := &file_agent_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAssignmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAssignmentsRequest) ProtoMessage() {}
+
+func (x *GetAssignmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAssignmentsRequest.ProtoReflect.Descriptor instead.
+func (*GetAssignmentsRequest) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetAssignmentsRequest) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+type GetAssignmentsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Assignments    []*Assignment          `protobuf:"bytes,1,rep,name=assignments,proto3" json:"assignments,omitempty"`
+	PollIntervalMs int64                  `protobuf:"varint,2,opt,name=poll_interval_ms,json=pollIntervalMs,proto3" json:"poll_interval_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetAssignmentsResponse) Reset() {
+	*x = GetAssignmentsResponse{}
+	mi := &file_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAssignmentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAssignmentsResponse) ProtoMessage() {}
+
+func (x *GetAssignmentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAssignmentsResponse.ProtoReflect.Descriptor instead.
+func (*GetAssignmentsResponse) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetAssignmentsResponse) GetAssignments() []*Assignment {
+	if x != nil {
+		return x.Assignments
+	}
+	return nil
+}
+
+func (x *GetAssignmentsResponse) GetPollIntervalMs() int64 {
+	if x != nil {
+		return x.PollIntervalMs
+	}
+	return 0
+}
+
+type Assignment struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	HealthApi string                 `protobuf:"bytes,2,opt,name=health_api,json=healthApi,proto3" json:"health_api,omitempty"`
+	// JSON encoded check config; empty means the defaults
+	CheckConfig string `protobuf:"bytes,3,opt,name=check_config,json=checkConfig,proto3" json:"check_config,omitempty"`
+	// Changes whenever the product or its check config changes
+	Version       int64 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assignment) Reset() {
+	*x = Assignment{}
+	mi := &file_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assignment) ProtoMessage() {}
+
+func (x *Assignment) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
+func (*Assignment) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Assignment) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *Assignment) GetHealthApi() string {
+	if x != nil {
+		return x.HealthApi
+	}
+	return ""
+}
+
+func (x *Assignment) GetCheckConfig() string {
+	if x != nil {
+		return x.CheckConfig
+	}
+	return ""
+}
+
+func (x *Assignment) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type CheckReport struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProductId       uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Success         bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	StatusCode      int32                  `protobuf:"varint,3,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Reason          string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	CheckedAtUnixMs int64                  `protobuf:"varint,5,opt,name=checked_at_unix_ms,json=checkedAtUnixMs,proto3" json:"checked_at_unix_ms,omitempty"`
+	DnsMs           float64                `protobuf:"fixed64,6,opt,name=dns_ms,json=dnsMs,proto3" json:"dns_ms,omitempty"`
+	ConnectMs       float64                `protobuf:"fixed64,7,opt,name=connect_ms,json=connectMs,proto3" json:"connect_ms,omitempty"`
+	TlsMs           float64                `protobuf:"fixed64,8,opt,name=tls_ms,json=tlsMs,proto3" json:"tls_ms,omitempty"`
+	TtfbMs          float64                `protobuf:"fixed64,9,opt,name=ttfb_ms,json=ttfbMs,proto3" json:"ttfb_ms,omitempty"`
+	TotalMs         float64                `protobuf:"fixed64,10,opt,name=total_ms,json=totalMs,proto3" json:"total_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CheckReport) Reset() {
+	*x = CheckReport{}
+	mi := &file_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckReport) ProtoMessage() {}
+
+func (x *CheckReport) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckReport.ProtoReflect.Descriptor instead.
+func (*CheckReport) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckReport) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *CheckReport) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CheckReport) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *CheckReport) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CheckReport) GetCheckedAtUnixMs() int64 {
+	if x != nil {
+		return x.CheckedAtUnixMs
+	}
+	return 0
+}
+
+func (x *CheckReport) GetDnsMs() float64 {
+	if x != nil {
+		return x.DnsMs
+	}
+	return 0
+}
+
+func (x *CheckReport) GetConnectMs() float64 {
+	if x != nil {
+		return x.ConnectMs
+	}
+	return 0
+}
+
+func (x *CheckReport) GetTlsMs() float64 {
+	if x != nil {
+		return x.TlsMs
+	}
+	return 0
+}
+
+func (x *CheckReport) GetTtfbMs() float64 {
+	if x != nil {
+		return x.TtfbMs
+	}
+	return 0
+}
+
+func (x *CheckReport) GetTotalMs() float64 {
+	if x != nil {
+		return x.TotalMs
+	}
+	return 0
+}
+
+type ReportResultsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      uint64                 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportResultsResponse) Reset() {
+	*x = ReportResultsResponse{}
+	mi := &file_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportResultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportResultsResponse) ProtoMessage() {}
+
+func (x *ReportResultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportResultsResponse.ProtoReflect.Descriptor instead.
+func (*ReportResultsResponse) Descriptor() ([]byte, []int) {
+	return file_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReportResultsResponse) GetAccepted() uint64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+var File_agent_proto protoreflect.FileDescriptor
+
+const file_agent_proto_rawDesc = "" +
+	"\n" +
+	"\vagent.proto\x12\x13opsbuddy.ping.agent\"<\n" +
+	"\x15GetAssignmentsRequest\x12#\n" +
+	"\ragent_version\x18\x01 \x01(\tR\fagentVersion\"\x85\x01\n" +
+	"\x16GetAssignmentsResponse\x12A\n" +
+	"\vassignments\x18\x01 \x03(\v2\x1f.opsbuddy.ping.agent.AssignmentR\vassignments\x12(\n" +
+	"\x10poll_interval_ms\x18\x02 \x01(\x03R\x0epollIntervalMs\"\x87\x01\n" +
+	"\n" +
+	"Assignment\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\rR\tproductId\x12\x1d\n" +
+	"\n" +
+	"health_api\x18\x02 \x01(\tR\thealthApi\x12!\n" +
+	"\fcheck_config\x18\x03 \x01(\tR\vcheckConfig\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\"\xad\x02\n" +
+	"\vCheckReport\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\rR\tproductId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x1f\n" +
+	"\vstatus_code\x18\x03 \x01(\x05R\n" +
+	"statusCode\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12+\n" +
+	"\x12checked_at_unix_ms\x18\x05 \x01(\x03R\x0fcheckedAtUnixMs\x12\x15\n" +
+	"\x06dns_ms\x18\x06 \x01(\x01R\x05dnsMs\x12\x1d\n" +
+	"\n" +
+	"connect_ms\x18\a \x01(\x01R\tconnectMs\x12\x15\n" +
+	"\x06tls_ms\x18\b \x01(\x01R\x05tlsMs\x12\x17\n" +
+	"\attfb_ms\x18\t \x01(\x01R\x06ttfbMs\x12\x19\n" +
+	"\btotal_ms\x18\n" +
+	" \x01(\x01R\atotalMs\"3\n" +
+	"\x15ReportResultsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x04R\baccepted2\xda\x01\n" +
+	"\fAgentService\x12i\n" +
+	"\x0eGetAssignments\x12*.opsbuddy.ping.agent.GetAssignmentsRequest\x1a+.opsbuddy.ping.agent.GetAssignmentsResponse\x12_\n" +
+	"\rReportResults\x12 .opsbuddy.ping.agent.CheckReport\x1a*.opsbuddy.ping.agent.ReportResultsResponse(\x01B\tZ\a./protob\x06proto3"
+
+var (
+	file_agent_proto_rawDescOnce sync.Once
+	file_agent_proto_rawDescData []byte
+)
+
+func file_agent_proto_rawDescGZIP() []byte {
+	file_agent_proto_rawDescOnce.Do(func() {
+		file_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)))
+	})
+	return file_agent_proto_rawDescData
+}
+
+var file_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_agent_proto_goTypes = []any{
+	(*GetAssignmentsRequest)(nil),  // 0: opsbuddy.ping.agent.GetAssignmentsRequest
+	(*GetAssignmentsResponse)(nil), // 1: opsbuddy.ping.agent.GetAssignmentsResponse
+	(*Assignment)(nil),             // 2: opsbuddy.ping.agent.Assignment
+	(*CheckReport)(nil),            // 3: opsbuddy.ping.agent.CheckReport
+	(*ReportResultsResponse)(nil),  // 4: opsbuddy.ping.agent.ReportResultsResponse
+}
+var file_agent_proto_depIdxs = []int32{
+	2, // 0: opsbuddy.ping.agent.GetAssignmentsResponse.assignments:type_name -> opsbuddy.ping.agent.Assignment
+	0, // 1: opsbuddy.ping.agent.AgentService.GetAssignments:input_type -> opsbuddy.ping.agent.GetAssignmentsRequest
+	3, // 2: opsbuddy.ping.agent.AgentService.ReportResults:input_type -> opsbuddy.ping.agent.CheckReport
+	1, // 3: opsbuddy.ping.agent.AgentService.GetAssignments:output_type -> opsbuddy.ping.agent.GetAssignmentsResponse
+	4, // 4: opsbuddy.ping.agent.AgentService.ReportResults:output_type -> opsbuddy.ping.agent.ReportResultsResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_agent_proto_init() }
+func file_agent_proto_init() {
+	if File_agent_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agent_proto_rawDesc), len(file_agent_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_agent_proto_goTypes,
+		DependencyIndexes: file_agent_proto_depIdxs,
+		MessageInfos:      file_agent_proto_msgTypes,
+	}.Build()
+	File_agent_proto = out.File
+	file_agent_proto_goTypes = nil
+	file_agent_proto_depIdxs = nil
+}
@@ -0,0 +1,397 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: proto/v2/ingestion.proto
+
+package v2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Severity int32
+
+const (
+	Severity_SEVERITY_UNSPECIFIED Severity = 0
+	Severity_SEVERITY_TRACE       Severity = 1
+	Severity_SEVERITY_DEBUG       Severity = 2
+	Severity_SEVERITY_INFO        Severity = 3
+	Severity_SEVERITY_WARN        Severity = 4
+	Severity_SEVERITY_ERROR       Severity = 5
+	Severity_SEVERITY_FATAL       Severity = 6
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "SEVERITY_UNSPECIFIED",
+		1: "SEVERITY_TRACE",
+		2: "SEVERITY_DEBUG",
+		3: "SEVERITY_INFO",
+		4: "SEVERITY_WARN",
+		5: "SEVERITY_ERROR",
+		6: "SEVERITY_FATAL",
+	}
+	Severity_value = map[string]int32{
+		"SEVERITY_UNSPECIFIED": 0,
+		"SEVERITY_TRACE":       1,
+		"SEVERITY_DEBUG":       2,
+		"SEVERITY_INFO":        3,
+		"SEVERITY_WARN":        4,
+		"SEVERITY_ERROR":       5,
+		"SEVERITY_FATAL":       6,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_v2_ingestion_proto_enumTypes[0].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_proto_v2_ingestion_proto_enumTypes[0]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_proto_v2_ingestion_proto_rawDescGZIP(), []int{0}
+}
+
+type IngestEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestEventResponse) Reset() {
+	*x = IngestEventResponse{}
+	mi := &file_proto_v2_ingestion_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestEventResponse) ProtoMessage() {}
+
+func (x *IngestEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v2_ingestion_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestEventResponse.ProtoReflect.Descriptor instead.
+func (*IngestEventResponse) Descriptor() ([]byte, []int) {
+	return file_proto_v2_ingestion_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *IngestEventResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type IngestEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*LogEntry            `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	ServiceId     string                 `protobuf:"bytes,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	AuthToken     string                 `protobuf:"bytes,3,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestEventRequest) Reset() {
+	*x = IngestEventRequest{}
+	mi := &file_proto_v2_ingestion_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestEventRequest) ProtoMessage() {}
+
+func (x *IngestEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v2_ingestion_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestEventRequest.ProtoReflect.Descriptor instead.
+func (*IngestEventRequest) Descriptor() ([]byte, []int) {
+	return file_proto_v2_ingestion_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *IngestEventRequest) GetLogs() []*LogEntry {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *IngestEventRequest) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *IngestEventRequest) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+type LogEntry struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp  string                 `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Message    string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Severity   Severity               `protobuf:"varint,3,opt,name=severity,proto3,enum=opsbuddy.sdk.ingestion.v2.Severity" json:"severity,omitempty"`
+	Attributes map[string]string      `protobuf:"bytes,4,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Name of the logger or module that wrote the entry
+	Logger   string `protobuf:"bytes,5,opt,name=logger,proto3" json:"logger,omitempty"`
+	Hostname string `protobuf:"bytes,6,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	// Instance of the service, e.g. a pod or process ID
+	Instance string `protobuf:"bytes,7,opt,name=instance,proto3" json:"instance,omitempty"`
+	// W3C trace context, hex encoded
+	TraceId       string `protobuf:"bytes,8,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId        string `protobuf:"bytes,9,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_proto_v2_ingestion_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_v2_ingestion_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_proto_v2_ingestion_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LogEntry) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *LogEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LogEntry) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSPECIFIED
+}
+
+func (x *LogEntry) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *LogEntry) GetLogger() string {
+	if x != nil {
+		return x.Logger
+	}
+	return ""
+}
+
+func (x *LogEntry) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *LogEntry) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+func (x *LogEntry) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *LogEntry) GetSpanId() string {
+	if x != nil {
+		return x.SpanId
+	}
+	return ""
+}
+
+var File_proto_v2_ingestion_proto protoreflect.FileDescriptor
+
+const file_proto_v2_ingestion_proto_rawDesc = "" +
+	"\n" +
+	"\x18proto/v2/ingestion.proto\x12\x19opsbuddy.sdk.ingestion.v2\"/\n" +
+	"\x13IngestEventResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x8b\x01\n" +
+	"\x12IngestEventRequest\x127\n" +
+	"\x04logs\x18\x01 \x03(\v2#.opsbuddy.sdk.ingestion.v2.LogEntryR\x04logs\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x02 \x01(\tR\tserviceId\x12\x1d\n" +
+	"\n" +
+	"auth_token\x18\x03 \x01(\tR\tauthToken\"\x9b\x03\n" +
+	"\bLogEntry\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12?\n" +
+	"\bseverity\x18\x03 \x01(\x0e2#.opsbuddy.sdk.ingestion.v2.SeverityR\bseverity\x12S\n" +
+	"\n" +
+	"attributes\x18\x04 \x03(\v23.opsbuddy.sdk.ingestion.v2.LogEntry.AttributesEntryR\n" +
+	"attributes\x12\x16\n" +
+	"\x06logger\x18\x05 \x01(\tR\x06logger\x12\x1a\n" +
+	"\bhostname\x18\x06 \x01(\tR\bhostname\x12\x1a\n" +
+	"\binstance\x18\a \x01(\tR\binstance\x12\x19\n" +
+	"\btrace_id\x18\b \x01(\tR\atraceId\x12\x17\n" +
+	"\aspan_id\x18\t \x01(\tR\x06spanId\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\x9a\x01\n" +
+	"\bSeverity\x12\x18\n" +
+	"\x14SEVERITY_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSEVERITY_TRACE\x10\x01\x12\x12\n" +
+	"\x0eSEVERITY_DEBUG\x10\x02\x12\x11\n" +
+	"\rSEVERITY_INFO\x10\x03\x12\x11\n" +
+	"\rSEVERITY_WARN\x10\x04\x12\x12\n" +
+	"\x0eSEVERITY_ERROR\x10\x05\x12\x12\n" +
+	"\x0eSEVERITY_FATAL\x10\x062\x83\x01\n" +
+	"\x10IngestionService\x12o\n" +
+	"\x0eIngestLogBatch\x12-.opsbuddy.sdk.ingestion.v2.IngestEventRequest\x1a..opsbuddy.sdk.ingestion.v2.IngestEventResponseB\fZ\n" +
+	"./proto/v2b\x06proto3"
+
+var (
+	file_proto_v2_ingestion_proto_rawDescOnce sync.Once
+	file_proto_v2_ingestion_proto_rawDescData []byte
+)
+
+func file_proto_v2_ingestion_proto_rawDescGZIP() []byte {
+	file_proto_v2_ingestion_proto_rawDescOnce.Do(func() {
+		file_proto_v2_ingestion_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_v2_ingestion_proto_rawDesc), len(file_proto_v2_ingestion_proto_rawDesc)))
+	})
+	return file_proto_v2_ingestion_proto_rawDescData
+}
+
+var file_proto_v2_ingestion_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_v2_ingestion_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_v2_ingestion_proto_goTypes = []any{
+	(Severity)(0),               // 0: opsbuddy.sdk.ingestion.v2.Severity
+	(*IngestEventResponse)(nil), // 1: opsbuddy.sdk.ingestion.v2.IngestEventResponse
+	(*IngestEventRequest)(nil),  // 2: opsbuddy.sdk.ingestion.v2.IngestEventRequest
+	(*LogEntry)(nil),            // 3: opsbuddy.sdk.ingestion.v2.LogEntry
+	nil,                         // 4: opsbuddy.sdk.ingestion.v2.LogEntry.AttributesEntry
+}
+var file_proto_v2_ingestion_proto_depIdxs = []int32{
+	3, // 0: opsbuddy.sdk.ingestion.v2.IngestEventRequest.logs:type_name -> opsbuddy.sdk.ingestion.v2.LogEntry
+	0, // 1: opsbuddy.sdk.ingestion.v2.LogEntry.severity:type_name -> opsbuddy.sdk.ingestion.v2.Severity
+	4, // 2: opsbuddy.sdk.ingestion.v2.LogEntry.attributes:type_name -> opsbuddy.sdk.ingestion.v2.LogEntry.AttributesEntry
+	2, // 3: opsbuddy.sdk.ingestion.v2.IngestionService.IngestLogBatch:input_type -> opsbuddy.sdk.ingestion.v2.IngestEventRequest
+	1, // 4: opsbuddy.sdk.ingestion.v2.IngestionService.IngestLogBatch:output_type -> opsbuddy.sdk.ingestion.v2.IngestEventResponse
+	4, // [4:5] is the sub-list for method output_type
+	3, // [3:4] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_v2_ingestion_proto_init() }
+func file_proto_v2_ingestion_proto_init() {
+	if File_proto_v2_ingestion_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_v2_ingestion_proto_rawDesc), len(file_proto_v2_ingestion_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_v2_ingestion_proto_goTypes,
+		DependencyIndexes: file_proto_v2_ingestion_proto_depIdxs,
+		EnumInfos:         file_proto_v2_ingestion_proto_enumTypes,
+		MessageInfos:      file_proto_v2_ingestion_proto_msgTypes,
+	}.Build()
+	File_proto_v2_ingestion_proto = out.File
+	file_proto_v2_ingestion_proto_goTypes = nil
+	file_proto_v2_ingestion_proto_depIdxs = nil
+}